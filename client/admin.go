@@ -0,0 +1,358 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/urfave/cli"
+)
+
+// sendAdminRequest broadcasts the admin action,
+// or writes it in the output for the other admins to cosign it.
+func sendAdminRequest(edKey crypto.PrivKeyEd25519, dd DeliveryData, reason, output string) error {
+	dr := AdminRequest(edKey, dd, reason)
+	if len(output) > 0 {
+		err := writeRequest(output, dr)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Created successfully the request in " + output + ", the other admins need to cosign it.")
+		return nil
+	}
+	_, err := BroadcastRequest(dr)
+	if err != nil {
+		return errors.New("Error: the transaction failed: " + err.Error())
+	}
+	fmt.Println("Successfully " + string(dd.Action) + " with the reason: " + reason)
+	return nil
+}
+
+func freezeCommand(action ActionStruct, usage string) cli.Command {
+	return cli.Command{
+		Name: string(action),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "the filename that contains the key of the admin in json file",
+			},
+			cli.StringSliceFlag{
+				Name:  "hash",
+				Usage: "the hash of the file",
+			},
+			cli.StringFlag{
+				Name:  "account",
+				Usage: "the address of the account",
+			},
+			cli.StringFlag{
+				Name:  "reason",
+				Usage: "the reason for the audit log",
+			},
+			cli.StringFlag{
+				Name:  "output",
+				Usage: "the filename for the request when more admins need to cosign it",
+			},
+		},
+		Usage: usage,
+		Action: func(c *cli.Context) error {
+			key := c.String("key")
+			if len(key) == 0 {
+				return errors.New("Error: the key is missing")
+			}
+
+			reason := c.String("reason")
+			if len(reason) == 0 {
+				return errors.New("Error: the reason is missing")
+			}
+
+			dd := DeliveryData{}
+			dd.Action = action
+			dd.Files = c.StringSlice("hash")
+			if account := c.String("account"); len(account) > 0 {
+				account, err := decodeAddress(account)
+				if err != nil {
+					return err
+				}
+				dd.Account = &account
+			}
+			if len(dd.Files) == 0 && dd.Account == nil {
+				return errors.New("Error: the hash or the account is missing")
+			}
+
+			edKey, err := fileKey(key)
+			if err != nil {
+				return err
+			}
+			return sendAdminRequest(*edKey, dd, reason, c.String("output"))
+		},
+	}
+}
+
+var Freeze = freezeCommand(FREEZE_ACTION, "freeze hashes or an account, so they can not be sent or removed")
+
+var Unfreeze = freezeCommand(UNFREEZE_ACTION, "unfreeze hashes or an account")
+
+func pauseCommand(action ActionStruct, usage string) cli.Command {
+	return cli.Command{
+		Name: string(action),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "the filename that contains the key of the admin in json file",
+			},
+			cli.StringFlag{
+				Name:  "reason",
+				Usage: "the reason for the audit log",
+			},
+			cli.StringFlag{
+				Name:  "output",
+				Usage: "the filename for the request when more admins need to cosign it",
+			},
+		},
+		Usage: usage,
+		Action: func(c *cli.Context) error {
+			key := c.String("key")
+			if len(key) == 0 {
+				return errors.New("Error: the key is missing")
+			}
+
+			reason := c.String("reason")
+			if len(reason) == 0 {
+				return errors.New("Error: the reason is missing")
+			}
+
+			edKey, err := fileKey(key)
+			if err != nil {
+				return err
+			}
+			return sendAdminRequest(*edKey, DeliveryData{Action: action}, reason, c.String("output"))
+		},
+	}
+}
+
+var Pause = pauseCommand(PAUSE_ACTION, "pause the chain, only the admin actions and the queries work until the unpause")
+
+var Unpause = pauseCommand(UNPAUSE_ACTION, "unpause the chain")
+
+func banCommand(action ActionStruct, usage string) cli.Command {
+	return cli.Command{
+		Name: string(action),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "the filename that contains the key of the admin in json file",
+			},
+			cli.StringSliceFlag{
+				Name:  "hash",
+				Usage: "the hash of the file",
+			},
+			cli.BoolFlag{
+				Name:  "force-remove",
+				Usage: "remove the banned hashes from their owners",
+			},
+			cli.StringFlag{
+				Name:  "reason",
+				Usage: "the reason for the audit log",
+			},
+			cli.StringFlag{
+				Name:  "output",
+				Usage: "the filename for the request when more admins need to cosign it",
+			},
+		},
+		Usage: usage,
+		Action: func(c *cli.Context) error {
+			key := c.String("key")
+			if len(key) == 0 {
+				return errors.New("Error: the key is missing")
+			}
+
+			hashes := c.StringSlice("hash")
+			if len(hashes) == 0 {
+				return errors.New("Error: the hash is empty")
+			}
+
+			reason := c.String("reason")
+			if len(reason) == 0 {
+				return errors.New("Error: the reason is missing")
+			}
+
+			edKey, err := fileKey(key)
+			if err != nil {
+				return err
+			}
+			dd := DeliveryData{}
+			dd.Action = action
+			dd.Files = hashes
+			dd.ForceRemove = c.Bool("force-remove")
+			return sendAdminRequest(*edKey, dd, reason, c.String("output"))
+		},
+	}
+}
+
+var Ban = banCommand(BAN_ACTION, "ban hashes, so they can not be added or sent")
+
+var Unban = banCommand(UNBAN_ACTION, "remove hashes from the blocklist")
+
+func registerCommand(action ActionStruct, usage string) cli.Command {
+	return cli.Command{
+		Name: string(action),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "the filename that contains the key of the admin in json file",
+			},
+			cli.StringFlag{
+				Name:  "account",
+				Usage: "the address of the account",
+			},
+			cli.StringFlag{
+				Name:  "reason",
+				Usage: "the reason for the audit log",
+			},
+			cli.StringFlag{
+				Name:  "output",
+				Usage: "the filename for the request when more admins need to cosign it",
+			},
+		},
+		Usage: usage,
+		Action: func(c *cli.Context) error {
+			key := c.String("key")
+			if len(key) == 0 {
+				return errors.New("Error: the key is missing")
+			}
+
+			account := c.String("account")
+			if len(account) == 0 {
+				return errors.New("Error: the account is missing")
+			}
+			account, err := decodeAddress(account)
+			if err != nil {
+				return err
+			}
+
+			reason := c.String("reason")
+			if len(reason) == 0 {
+				return errors.New("Error: the reason is missing")
+			}
+
+			edKey, err := fileKey(key)
+			if err != nil {
+				return err
+			}
+			dd := DeliveryData{}
+			dd.Action = action
+			dd.Account = &account
+			return sendAdminRequest(*edKey, dd, reason, c.String("output"))
+		},
+	}
+}
+
+var Register = registerCommand(REGISTER_ACTION, "register an account for the permissioned chain")
+
+var Unregister = registerCommand(UNREGISTER_ACTION, "unregister an account from the permissioned chain")
+
+var ForcedTransfer = cli.Command{
+	Name: "forced-transfer",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of the admin in json file",
+		},
+		cli.StringFlag{
+			Name:  "account",
+			Usage: "the address of the owner",
+		},
+		cli.StringFlag{
+			Name:  "receiver",
+			Usage: "the public key, the address or the @name of the receiver",
+		},
+		cli.StringSliceFlag{
+			Name:  "hash",
+			Usage: "the hash of the file",
+		},
+		cli.StringFlag{
+			Name:  "reason",
+			Usage: "the reason for the audit log",
+		},
+		cli.StringFlag{
+			Name:  "output",
+			Usage: "the filename for the request when more admins need to cosign it",
+		},
+	},
+	Usage: "transfer the hashes of an account to a receiver by order",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		account := c.String("account")
+		if len(account) == 0 {
+			return errors.New("Error: the account is missing")
+		}
+		account, err := decodeAddress(account)
+		if err != nil {
+			return err
+		}
+
+		receiver := c.String("receiver")
+		if len(receiver) == 0 {
+			return errors.New("Error: the receiver is empty")
+		}
+
+		hashes := c.StringSlice("hash")
+		if len(hashes) == 0 {
+			return errors.New("Error: the hash is empty")
+		}
+
+		reason := c.String("reason")
+		if len(reason) == 0 {
+			return errors.New("Error: the reason is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		b, err := receiverPublicKey(receiver)
+		if err != nil {
+			return err
+		}
+		dd := DeliveryData{}
+		dd.Action = FORCED_TRANSFER_ACTION
+		dd.Account = &account
+		dd.To = &b
+		dd.Files = hashes
+		return sendAdminRequest(*edKey, dd, reason, c.String("output"))
+	},
+}
+
+var Audit = cli.Command{
+	Name:  "audit",
+	Usage: "show the audit log of the admin actions",
+	Action: func(c *cli.Context) error {
+		entries, err := AuditRequest()
+		if err != nil {
+			return errors.New("Error: the query failed: " + err.Error())
+		}
+		for _, v := range entries {
+			line := strconv.FormatInt(v.Height, 10) + " " + string(v.Action) + " by " + strings.Join(encodeAddresses(v.Admins), ", ")
+			if len(v.Files) > 0 {
+				line += " hashes " + strings.Join(v.Files, ", ")
+			}
+			if len(v.Account) > 0 {
+				line += " account " + encodeAddress(v.Account)
+			}
+			if len(v.To) > 0 {
+				line += " to " + encodeAddress(v.To)
+			}
+			if len(v.Issuers) > 0 {
+				line += " issuers " + strings.Join(encodeAddresses(v.Issuers), ", ")
+			}
+			fmt.Println(line + ": " + v.Reason)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+var Approve = cli.Command{
+	Name: "approve",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "delegate",
+			Usage: "the address of the delegate",
+		},
+		cli.StringSliceFlag{
+			Name:  "hash",
+			Usage: "the hash that the delegate can send, any hash when it is empty",
+		},
+		cli.IntFlag{
+			Name:  "count",
+			Usage: "the number of hashes that the delegate can send",
+		},
+		cli.Int64Flag{
+			Name:  "expiry",
+			Usage: "the block height that the approval expires",
+		},
+	},
+	Usage: "approve a delegate to send your hashes, without count and expiry there is no limit",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		delegate := c.String("delegate")
+		if len(delegate) == 0 {
+			return errors.New("Error: the delegate is missing")
+		}
+		delegate, err := decodeAddress(delegate)
+		if err != nil {
+			return err
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		count := c.Int("count")
+		expiry := c.Int64("expiry")
+		_, err = ApproveRequest(*edKey, delegate, c.StringSlice("hash"), count, expiry)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully approved " + delegate)
+		return nil
+	},
+}
+
+var ApproveRevoke = cli.Command{
+	Name: "approve-revoke",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "delegate",
+			Usage: "the address of the delegate",
+		},
+	},
+	Usage: "revoke the approval of a delegate",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		delegate := c.String("delegate")
+		if len(delegate) == 0 {
+			return errors.New("Error: the delegate is missing")
+		}
+		delegate, err := decodeAddress(delegate)
+		if err != nil {
+			return err
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = ApproveRevokeRequest(*edKey, delegate)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully revoked the approval of " + delegate)
+		return nil
+	},
+}
+
+var SendFrom = cli.Command{
+	Name: "send-from",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of the delegate in json file",
+		},
+		cli.StringFlag{
+			Name:  "account",
+			Usage: "the address of the owner",
+		},
+		cli.StringFlag{
+			Name:  "receiver",
+			Usage: "the public key, the address or the @name of the receiver",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the hash of the file",
+		},
+	},
+	Usage: "send a hash of an owner that approved you",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		account := c.String("account")
+		if len(account) == 0 {
+			return errors.New("Error: the account is missing")
+		}
+		account, err := decodeAddress(account)
+		if err != nil {
+			return err
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: the hash is empty")
+		}
+
+		receiver := c.String("receiver")
+		if len(receiver) == 0 {
+			return errors.New("Error: the receiver is empty")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		b, err := receiverPublicKey(receiver)
+		if err != nil {
+			return err
+		}
+		_, err = SendFromRequest(*edKey, account, b, []string{hash})
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully send the hash " + hash + " from " + account + " to " + receiver)
+		return nil
+	},
+}
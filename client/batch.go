@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/urfave/cli"
+)
+
+// BatchOperation is an operation in the input file of the batch command.
+type BatchOperation struct {
+	Action   ActionStruct
+	Hashes   []string
+	Receiver string `json:",omitempty"` // only for send
+}
+
+var Batch = cli.Command{
+	Name: "batch",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "input",
+			Usage: "the json file with the list of the add, remove and send operations in order",
+		},
+	},
+	Usage: "add, remove and send hashes in one transaction, all of them succeed or none",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		input := c.String("input")
+		if len(input) == 0 {
+			return errors.New("Error: the input is missing")
+		}
+
+		b, err := ioutil.ReadFile(input)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		bos := []BatchOperation{}
+		err = json.Unmarshal(b, &bos)
+		if err != nil {
+			return errors.New("Error: json problem with the input " + err.Error())
+		}
+		if len(bos) == 0 {
+			return errors.New("Error: the operations are missing")
+		}
+
+		operations := []DeliveryData{}
+		for _, v := range bos {
+			dd := DeliveryData{Action: v.Action, Files: v.Hashes}
+			switch v.Action {
+			case ADD_ACTION, REMOVE_ACTION:
+			case SEND_ACTION:
+				if len(v.Receiver) == 0 {
+					return errors.New("Error: the receiver of the send is empty")
+				}
+				to, err := receiverPublicKey(v.Receiver)
+				if err != nil {
+					return err
+				}
+				dd.To = &to
+			default:
+				return errors.New("Error: the action " + string(v.Action) + " is not 'add', 'remove' or 'send'")
+			}
+			operations = append(operations, dd)
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = BatchRequest(*edKey, operations)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully applied the " + strconv.Itoa(len(operations)) + " operations")
+		return nil
+	},
+}
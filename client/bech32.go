@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	crypto "github.com/tendermint/go-crypto"
+)
+
+// the bech32 format of BIP-173, the human-readable part is the prefix of the chain
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var bech32Generator = []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func bech32Polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= bech32Generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	b := []byte{}
+	for i := 0; i < len(hrp); i++ {
+		b = append(b, hrp[i]>>5)
+	}
+	b = append(b, 0)
+	for i := 0; i < len(hrp); i++ {
+		b = append(b, hrp[i]&31)
+	}
+	return b
+}
+
+func bech32Checksum(hrp string, data []byte) []byte {
+	values := append(bech32HrpExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	polymod := bech32Polymod(values) ^ 1
+	checksum := make([]byte, 6)
+	for i := range checksum {
+		checksum[i] = byte((polymod >> uint(5*(5-i))) & 31)
+	}
+	return checksum
+}
+
+// convertBits regroups the bits of the data from the fromBits to the toBits size
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	acc := uint32(0)
+	bits := uint(0)
+	maxv := uint32(1)<<toBits - 1
+	b := []byte{}
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, errors.New("The data has an invalid value.")
+		}
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			b = append(b, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			b = append(b, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, errors.New("The data has an invalid padding.")
+	}
+	return b, nil
+}
+
+func bech32Encode(hrp string, data []byte) (string, error) {
+	conv, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	conv = append(conv, bech32Checksum(hrp, conv)...)
+	s := hrp + "1"
+	for _, v := range conv {
+		s += string(bech32Charset[v])
+	}
+	return s, nil
+}
+
+func bech32Decode(s string) (string, []byte, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, errors.New("The bech32 string has mixed case.")
+	}
+	s = strings.ToLower(s)
+	pos := strings.LastIndex(s, "1")
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, errors.New("The bech32 string has no separator or checksum.")
+	}
+	hrp := s[:pos]
+	data := []byte{}
+	for i := pos + 1; i < len(s); i++ {
+		d := strings.IndexByte(bech32Charset, s[i])
+		if d < 0 {
+			return "", nil, errors.New("The bech32 string has an invalid character.")
+		}
+		data = append(data, byte(d))
+	}
+	if bech32Polymod(append(bech32HrpExpand(hrp), data...)) != 1 {
+		return "", nil, errors.New("The bech32 string has an invalid checksum.")
+	}
+	b, err := convertBits(data[:len(data)-6], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, b, nil
+}
+
+func pubKeyPrefix() string {
+	return Conf.Bech32Prefix + "pub"
+}
+
+// encodePublicKey returns the public key in bech32 with the prefix of the chain
+func encodePublicKey(b []byte) string {
+	s, _ := bech32Encode(pubKeyPrefix(), b)
+	return s
+}
+
+// encodeAddress returns the address, in the hex of the chain, in bech32 with the prefix of the chain
+func encodeAddress(addr string) string {
+	b, err := hex.DecodeString(addr)
+	if err != nil {
+		return addr
+	}
+	s, _ := bech32Encode(Conf.Bech32Prefix, b)
+	return s
+}
+
+// decodeKey decodes a public key or an address from bech32,
+// or from hex for the compatibility with the older keys.
+func decodeKey(s string) ([]byte, error) {
+	hrp, b, err := bech32Decode(s)
+	if err == nil {
+		if hrp != Conf.Bech32Prefix && hrp != pubKeyPrefix() {
+			return nil, errors.New("Error: the prefix " + hrp + " is not of this chain")
+		}
+		return b, nil
+	}
+	b, hexErr := hex.DecodeString(s)
+	if hexErr != nil {
+		return nil, errors.New("Error: " + s + " is not bech32 or hex: " + err.Error())
+	}
+	return b, nil
+}
+
+// addressLength is the bytes of an address, like on the chain.
+const addressLength = 20
+
+// decodeAddress returns the address in the hex that the chain uses,
+// from the bech32 of an address or a public key, or from hex.
+func decodeAddress(s string) (string, error) {
+	hrp, b, err := bech32Decode(s)
+	if err != nil {
+		b, hexErr := hex.DecodeString(s)
+		if hexErr != nil {
+			return "", errors.New("Error: " + s + " is not bech32 or hex: " + err.Error())
+		}
+		if len(b) != addressLength {
+			return "", errors.New("Error: " + s + " is not an address of 20 bytes")
+		}
+		return strings.ToUpper(s), nil
+	}
+	if hrp == pubKeyPrefix() {
+		pubKey, err := crypto.PubKeyFromBytes(b)
+		if err != nil {
+			return "", errors.New("Error: " + s + " is not a public key: " + err.Error())
+		}
+		return pubKey.Address().String(), nil
+	}
+	if hrp != Conf.Bech32Prefix {
+		return "", errors.New("Error: the prefix " + hrp + " is not of this chain")
+	}
+	if len(b) != addressLength {
+		return "", errors.New("Error: " + s + " is not an address of 20 bytes")
+	}
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+func encodeAddresses(addrs []string) []string {
+	s := []string{}
+	for _, v := range addrs {
+		s = append(s, encodeAddress(v))
+	}
+	return s
+}
+
+func decodeAddresses(addrs []string) ([]string, error) {
+	s := []string{}
+	for _, v := range addrs {
+		addr, err := decodeAddress(v)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, addr)
+	}
+	return s, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBech32DecodeValidStrings(t *testing.T) {
+	hrp, b, err := bech32Decode("A12UEL5L")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", hrp)
+	assert.Equal(t, 0, len(b))
+
+	hrp, _, err = bech32Decode("abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw")
+	assert.Nil(t, err)
+	assert.Equal(t, "abcdef", hrp)
+}
+
+func TestBech32DecodeFailOnWrongChecksum(t *testing.T) {
+	_, _, err := bech32Decode("abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxx")
+	assert.NotNil(t, err)
+	_, _, err = bech32Decode("A12uEL5L")
+	assert.NotNil(t, err)
+}
+
+func TestBech32PublicKeyAndAddressRoundTrip(t *testing.T) {
+	pubKey, _ := hex.DecodeString("1624de6420e1f6e2b1a9e44d2d7c4a60a7b1a0c5b1b5b2c3e8f9a0b1c2d3e4f5a6b7")
+	s := encodePublicKey(pubKey)
+	assert.Equal(t, "pbpub1", s[:6])
+	b, err := decodeKey(s)
+	assert.Nil(t, err)
+	assert.Equal(t, pubKey, b)
+
+	addr := "0F1E2D3C4B5A69788796A5B4C3D2E1F00F1E2D3C"
+	s = encodeAddress(addr)
+	assert.Equal(t, "pb1", s[:3])
+	decoded, err := decodeAddress(s)
+	assert.Nil(t, err)
+	assert.Equal(t, addr, decoded)
+}
+
+func TestBech32AcceptsHexForCompatibility(t *testing.T) {
+	addr := "0F1E2D3C4B5A69788796A5B4C3D2E1F00F1E2D3C"
+	decoded, err := decodeAddress("0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c")
+	assert.Nil(t, err)
+	assert.Equal(t, addr, decoded)
+
+	b, err := decodeKey(addr)
+	assert.Nil(t, err)
+	assert.Equal(t, 20, len(b))
+
+	// the hex of a public key or of any other length is not an address
+	_, err = decodeAddress("0f1e2d3c")
+	assert.NotNil(t, err)
+	_, err = decodeAddress("1624de6420e1f6e2b1a9e44d2d7c4a60a7b1a0c5b1b5b2c3e8f9a0b1c2d3e4f5a6b7")
+	assert.NotNil(t, err)
+	s, err := bech32Encode(Conf.Bech32Prefix, []byte{1, 2, 3})
+	assert.Nil(t, err)
+	_, err = decodeAddress(s)
+	assert.NotNil(t, err)
+}
+
+func TestBech32FailOnPrefixOfOtherChain(t *testing.T) {
+	s, err := bech32Encode("cosmos", []byte{1, 2, 3})
+	assert.Nil(t, err)
+	_, err = decodeKey(s)
+	assert.NotNil(t, err)
+	_, err = decodeAddress(s)
+	assert.NotNil(t, err)
+}
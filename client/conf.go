@@ -11,6 +11,7 @@ type configuration struct {
 	IpfsConnection string
 	Blockchain     BlockchainType
 	AbciDaemon     string
+	Bech32Prefix   string // the prefix of the addresses, the public keys have the prefix with 'pub'
 }
 
 var Conf = configuration{}
@@ -19,4 +20,5 @@ func init() {
 	Conf.IpfsConnection = "127.0.0.1:5001"
 	Conf.AbciDaemon = "http://0.0.0.0:26657"
 	Conf.Blockchain = SPB
+	Conf.Bech32Prefix = "pb"
 }
@@ -6,6 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
 
 	crypto "github.com/tendermint/go-crypto"
 	"github.com/urfave/cli"
@@ -15,6 +18,7 @@ type JsonKey struct {
 	PrivateKey string
 	PublicKey  string
 	PublicAddr string
+	Account    string `json:",omitempty"` // the account that the key signs for as a sub-key
 }
 
 var GenerateKey = cli.Command{
@@ -36,14 +40,14 @@ var GenerateKey = cli.Command{
 		privHex := hex.EncodeToString(edKey.Bytes())
 		jk := JsonKey{}
 		jk.PrivateKey = privHex
-		jk.PublicKey = hex.EncodeToString(edKey.PubKey().Bytes())
-		jk.PublicAddr = edKey.PubKey().Address().String()
+		jk.PublicKey = encodePublicKey(edKey.PubKey().Bytes())
+		jk.PublicAddr = encodeAddress(edKey.PubKey().Address().String())
 		b, _ := json.Marshal(jk)
 		err := ioutil.WriteFile(filename, b, 0644)
 		if err != nil {
 			return errors.New("Error: " + err.Error())
 		}
-		fmt.Println("Created successfully the key.")
+		fmt.Println("Created successfully the key with the address " + jk.PublicAddr)
 		return nil
 	},
 }
@@ -75,6 +79,10 @@ var Add = cli.Command{
 				"When the type is directory, it expects a directory.\n" +
 				"When the type is file, it expects a file",
 		},
+		cli.StringFlag{
+			Name:  "schema",
+			Usage: "the hash of the registered json schema that the json follows, a json object needs it when the chain has schemas",
+		},
 	},
 	Usage: "add a hash to the blockchain",
 	Action: func(c *cli.Context) error {
@@ -92,6 +100,14 @@ var Add = cli.Command{
 			return errors.New("Error: the input is empty")
 		}
 
+		var schema *string
+		if s := c.String("schema"); len(s) > 0 {
+			if tp != JSON_TYPE {
+				return errors.New("Error: only the json can have a schema")
+			}
+			schema = &s
+		}
+
 		edKey, err := fileKey(key)
 		if err != nil {
 			return err
@@ -109,7 +125,7 @@ var Add = cli.Command{
 			return errors.New("Error: IPFS problem " + err.Error())
 		}
 
-		_, err = AddRequest(*edKey, []string{hash})
+		_, err = SchemaAddRequest(*edKey, []string{hash}, schema)
 		if err != nil {
 			return errors.New("Error: the transaction failed: " + err.Error())
 		}
@@ -168,12 +184,24 @@ var Send = cli.Command{
 		},
 		cli.StringFlag{
 			Name:  "receiver",
-			Usage: "the public key of the receiver",
+			Usage: "the public key, the address or the @name of the receiver",
+		},
+		cli.StringFlag{
+			Name:  "receiver-multisig",
+			Usage: "the multisig address of the receiver, instead of the public key",
 		},
 		cli.StringFlag{
 			Name:  "hash",
 			Usage: "the hash of the file",
 		},
+		cli.Int64Flag{
+			Name:  "vest-height",
+			Usage: "the block height that the receiver can use the hash",
+		},
+		cli.StringFlag{
+			Name:  "vest-time",
+			Usage: "the block time that the receiver can use the hash, in RFC3339",
+		},
 	},
 	Usage: "send a hash to another person",
 	Action: func(c *cli.Context) error {
@@ -188,19 +216,49 @@ var Send = cli.Command{
 		}
 
 		receiver := c.String("receiver")
-		if len(hash) == 0 {
+		receiverMultisig := c.String("receiver-multisig")
+		if len(receiver) == 0 && len(receiverMultisig) == 0 {
 			return errors.New("Error: the receiver is empty")
 		}
 
+		vesting := VestingLock{Height: c.Int64("vest-height")}
+		if vestTime := c.String("vest-time"); len(vestTime) > 0 {
+			t, err := time.Parse(time.RFC3339, vestTime)
+			if err != nil {
+				return errors.New("Error: the vest time is not correct: " + err.Error())
+			}
+			vesting.Time = t.Unix()
+		}
+		isVesting := vesting.Height > 0 || vesting.Time > 0
+		if isVesting && len(receiverMultisig) > 0 {
+			return errors.New("Error: the vesting needs the public key of the receiver")
+		}
+
 		edKey, err := fileKey(key)
 		if err != nil {
 			return err
 		}
-		b, err := hex.DecodeString(receiver)
+		if len(receiverMultisig) > 0 {
+			receiverMultisig, err := decodeAddress(receiverMultisig)
+			if err != nil {
+				return err
+			}
+			_, err = SendToMultisigRequest(*edKey, receiverMultisig, []string{hash})
+			if err != nil {
+				return errors.New("Error: the transaction failed: " + err.Error())
+			}
+			fmt.Println("Successfully send the hash " + hash + " to " + receiverMultisig)
+			return nil
+		}
+		b, err := receiverPublicKey(receiver)
 		if err != nil {
 			return err
 		}
-		_, err = SendRequest(*edKey, b, []string{hash})
+		if isVesting {
+			_, err = VestingSendRequest(*edKey, b, []string{hash}, vesting)
+		} else {
+			_, err = SendRequest(*edKey, b, []string{hash})
+		}
 		if err != nil {
 			return errors.New("Error: the transaction failed: " + err.Error())
 		}
@@ -209,6 +267,55 @@ var Send = cli.Command{
 	},
 }
 
+var SendMany = cli.Command{
+	Name: "send-many",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringSliceFlag{
+			Name:  "recipient",
+			Usage: "the receiver and its hashes like '<receiver>:<hash>,<hash>', it can be repeated",
+		},
+	},
+	Usage: "send hashes to many receivers in one transaction",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		recipientFlags := c.StringSlice("recipient")
+		if len(recipientFlags) == 0 {
+			return errors.New("Error: the recipients are missing")
+		}
+		recipients := []Recipient{}
+		for _, v := range recipientFlags {
+			parts := strings.SplitN(v, ":", 2)
+			if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+				return errors.New("Error: the recipient " + v + " is not like '<receiver>:<hash>,<hash>'")
+			}
+			b, err := receiverPublicKey(parts[0])
+			if err != nil {
+				return err
+			}
+			recipients = append(recipients, Recipient{To: b, Files: strings.Split(parts[1], ",")})
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = RecipientsSendRequest(*edKey, recipients)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully send the hashes to " + strconv.Itoa(len(recipients)) + " receivers")
+		return nil
+	},
+}
+
 var Query = cli.Command{
 	Name:    "query",
 	Aliases: []string{"q"},
@@ -250,6 +357,10 @@ var Query = cli.Command{
 		addrStr := c.String("addr")
 		var addr *string
 		if len(addrStr) > 0 {
+			addrStr, err := decodeAddress(addrStr)
+			if err != nil {
+				return err
+			}
 			addr = &addrStr
 		}
 
@@ -270,6 +381,135 @@ var Query = cli.Command{
 		for _, v := range qr.Files {
 			fmt.Println(v)
 		}
+		if len(qr.InEscrow) > 0 {
+			fmt.Println("In escrow:")
+			for _, v := range qr.InEscrow {
+				fmt.Println(v)
+			}
+		}
+		if len(qr.Vesting) > 0 {
+			fmt.Println("Vesting:")
+			for _, v := range qr.Vesting {
+				until := []string{}
+				if v.Until.Height > 0 {
+					until = append(until, "block "+strconv.FormatInt(v.Until.Height, 10))
+				}
+				if v.Until.Time > 0 {
+					until = append(until, time.Unix(v.Until.Time, 0).UTC().Format(time.RFC3339))
+				}
+				fmt.Println(v.File + " until " + strings.Join(until, " and "))
+			}
+		}
+		if len(qr.Approvals) > 0 {
+			fmt.Println("Approvals:")
+			for _, v := range qr.Approvals {
+				approval := encodeAddress(v.Delegate)
+				if len(v.Files) > 0 {
+					approval += " for " + strings.Join(v.Files, ", ")
+				}
+				if v.Count > 0 {
+					approval += " up to " + strconv.Itoa(v.Count) + " hashes"
+				}
+				if v.Expiry > 0 {
+					approval += " until the block " + strconv.FormatInt(v.Expiry, 10)
+				}
+				fmt.Println(approval)
+			}
+		}
+		if len(qr.Issuers) > 0 {
+			fmt.Println("Issuers:")
+			for _, v := range qr.Issuers {
+				fmt.Println(v.File + " by " + encodeAddress(v.Issuer))
+			}
+		}
+		if len(qr.Schemas) > 0 {
+			fmt.Println("Schemas:")
+			for _, v := range qr.Schemas {
+				fmt.Println(v.File + " follows " + v.Schema)
+			}
+		}
+		return nil
+	},
+}
+
+var CoSign = cli.Command{
+	Name: "cosign",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "input",
+			Usage: "the filename of the request that will be signed",
+		},
+		cli.BoolFlag{
+			Name:  "broadcast",
+			Usage: "broadcast the request after signing it",
+		},
+	},
+	Usage: "add your signature to a request that another key created",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		input := c.String("input")
+		if len(input) == 0 {
+			return errors.New("Error: the input is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		dr, err := readRequest(input)
+		if err != nil {
+			return err
+		}
+		signed := CoSignRequest(*edKey, *dr)
+		if !c.Bool("broadcast") {
+			err = writeRequest(input, signed)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Successfully signed the request in " + input)
+			return nil
+		}
+		_, err = BroadcastRequest(signed)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully signed and broadcasted the request")
+		return nil
+	},
+}
+
+var Broadcast = cli.Command{
+	Name: "broadcast",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "input",
+			Usage: "the filename of the signed request",
+		},
+	},
+	Usage: "broadcast a request that has collected its signatures",
+	Action: func(c *cli.Context) error {
+		input := c.String("input")
+		if len(input) == 0 {
+			return errors.New("Error: the input is missing")
+		}
+
+		dr, err := readRequest(input)
+		if err != nil {
+			return err
+		}
+		_, err = BroadcastRequest(*dr)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully broadcasted the request")
 		return nil
 	},
 }
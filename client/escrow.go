@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+var EscrowCreate = cli.Command{
+	Name: "escrow",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of the seller in json file",
+		},
+		cli.StringFlag{
+			Name:  "buyer",
+			Usage: "the public key, the address or the @name of the buyer",
+		},
+		cli.StringFlag{
+			Name:  "arbiter",
+			Usage: "the public key or the @name of the arbiter",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the hash of the file",
+		},
+	},
+	Usage: "give a hash to the chain until two of the seller, the buyer and the arbiter decide",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: the hash is empty")
+		}
+
+		buyer := c.String("buyer")
+		if len(buyer) == 0 {
+			return errors.New("Error: the buyer is empty")
+		}
+
+		arbiter := c.String("arbiter")
+		if len(arbiter) == 0 {
+			return errors.New("Error: the arbiter is empty")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		buyerB, err := receiverPublicKey(buyer)
+		if err != nil {
+			return err
+		}
+		arbiterB, err := receiverPublicKey(arbiter)
+		if err != nil {
+			return err
+		}
+		_, err = EscrowRequest(*edKey, buyerB, arbiterB, []string{hash})
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully put the hash " + hash + " in escrow for " + buyer)
+		return nil
+	},
+}
+
+func escrowSettleCommand(name string, action ActionStruct, usage string) cli.Command {
+	return cli.Command{
+		Name: name,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "the filename that contains the key of one of the parties in json file",
+			},
+			cli.StringFlag{
+				Name:  "hash",
+				Usage: "the hash of the file",
+			},
+			cli.StringFlag{
+				Name:  "output",
+				Usage: "the filename that the request will be saved for the second party to cosign",
+			},
+		},
+		Usage: usage,
+		Action: func(c *cli.Context) error {
+			key := c.String("key")
+			if len(key) == 0 {
+				return errors.New("Error: the key is missing")
+			}
+
+			hash := c.String("hash")
+			if len(hash) == 0 {
+				return errors.New("Error: the hash is empty")
+			}
+
+			output := c.String("output")
+			if len(output) == 0 {
+				return errors.New("Error: the output is missing")
+			}
+
+			edKey, err := fileKey(key)
+			if err != nil {
+				return err
+			}
+			dr := EscrowSettleRequest(*edKey, action, []string{hash})
+			err = writeRequest(output, dr)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Created successfully the request in " + output + ", a second party needs to cosign it.")
+			return nil
+		},
+	}
+}
+
+var EscrowRelease = escrowSettleCommand("escrow-release", ESCROW_RELEASE_ACTION,
+	"sign the release of a hash in escrow to the buyer")
+
+var EscrowRefund = escrowSettleCommand("escrow-refund", ESCROW_REFUND_ACTION,
+	"sign the refund of a hash in escrow to the seller")
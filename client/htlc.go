@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+var HtlcLock = cli.Command{
+	Name: "htlc-lock",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "receiver",
+			Usage: "the public key, the address or the @name of the receiver",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the hash of the file",
+		},
+		cli.StringFlag{
+			Name:  "secret-hash",
+			Usage: "the sha256 of the secret in hex",
+		},
+		cli.StringFlag{
+			Name:  "secret",
+			Usage: "the secret, when the secret-hash is not given",
+		},
+		cli.Int64Flag{
+			Name:  "timeout",
+			Usage: "the block height that the lock expires",
+		},
+	},
+	Usage: "lock a hash for the receiver until the secret is revealed or the timeout",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: the hash is empty")
+		}
+
+		receiver := c.String("receiver")
+		if len(receiver) == 0 {
+			return errors.New("Error: the receiver is empty")
+		}
+
+		timeout := c.Int64("timeout")
+		if timeout <= 0 {
+			return errors.New("Error: the timeout is missing")
+		}
+
+		var secretHash []byte
+		if secretHashStr := c.String("secret-hash"); len(secretHashStr) > 0 {
+			b, err := hex.DecodeString(secretHashStr)
+			if err != nil {
+				return err
+			}
+			secretHash = b
+		} else if secret := c.String("secret"); len(secret) > 0 {
+			sum := sha256.Sum256([]byte(secret))
+			secretHash = sum[:]
+		} else {
+			return errors.New("Error: the secret-hash or the secret is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		b, err := receiverPublicKey(receiver)
+		if err != nil {
+			return err
+		}
+		_, err = HtlcLockRequest(*edKey, b, []string{hash}, secretHash, timeout)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully locked the hash " + hash + " for " + receiver +
+			" with the secret hash " + hex.EncodeToString(secretHash))
+		return nil
+	},
+}
+
+var HtlcClaim = cli.Command{
+	Name: "htlc-claim",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of the receiver in json file",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the hash of the file",
+		},
+		cli.StringFlag{
+			Name:  "secret",
+			Usage: "the secret that unlocks the hash",
+		},
+	},
+	Usage: "claim a locked hash by revealing the secret",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: the hash is empty")
+		}
+
+		secret := c.String("secret")
+		if len(secret) == 0 {
+			return errors.New("Error: the secret is empty")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = HtlcClaimRequest(*edKey, []string{hash}, []byte(secret))
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully claimed the hash " + hash)
+		return nil
+	},
+}
+
+var HtlcReclaim = cli.Command{
+	Name: "htlc-reclaim",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of the sender in json file",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the hash of the file",
+		},
+	},
+	Usage: "reclaim a locked hash after its timeout",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: the hash is empty")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = HtlcReclaimRequest(*edKey, []string{hash})
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully reclaimed the hash " + hash)
+		return nil
+	},
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+var Inheritance = cli.Command{
+	Name: "inheritance",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "beneficiary",
+			Usage: "the address of the beneficiary",
+		},
+		cli.Int64Flag{
+			Name:  "period",
+			Usage: "the number of blocks without a transaction that the beneficiary can claim the hashes",
+		},
+	},
+	Usage: "designate the beneficiary of your hashes when you are inactive",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		beneficiary := c.String("beneficiary")
+		if len(beneficiary) == 0 {
+			return errors.New("Error: the beneficiary is missing")
+		}
+		beneficiary, err := decodeAddress(beneficiary)
+		if err != nil {
+			return err
+		}
+
+		period := c.Int64("period")
+		if period <= 0 {
+			return errors.New("Error: the period is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = InheritanceRequest(*edKey, beneficiary, period)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully designated the beneficiary " + beneficiary)
+		return nil
+	},
+}
+
+var ClaimInheritance = cli.Command{
+	Name: "claim-inheritance",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of the beneficiary in json file",
+		},
+		cli.StringFlag{
+			Name:  "account",
+			Usage: "the address of the inactive account",
+		},
+	},
+	Usage: "claim all the hashes of an inactive account as its beneficiary",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		account := c.String("account")
+		if len(account) == 0 {
+			return errors.New("Error: the account is missing")
+		}
+		account, err := decodeAddress(account)
+		if err != nil {
+			return err
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = ClaimInheritanceRequest(*edKey, account)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully claimed the hashes of " + account)
+		return nil
+	},
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+var SetIssuers = cli.Command{
+	Name: "set-issuers",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of the admin in json file",
+		},
+		cli.StringSliceFlag{
+			Name:  "issuer",
+			Usage: "the address of an issuer, it can be repeated, without issuers everyone can add",
+		},
+		cli.StringFlag{
+			Name:  "reason",
+			Usage: "the reason for the audit log",
+		},
+		cli.StringFlag{
+			Name:  "output",
+			Usage: "the filename for the request when more admins need to cosign it",
+		},
+	},
+	Usage: "set the addresses that can add hashes",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		reason := c.String("reason")
+		if len(reason) == 0 {
+			return errors.New("Error: the reason is missing")
+		}
+
+		issuers, err := decodeAddresses(c.StringSlice("issuer"))
+		if err != nil {
+			return err
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		dd := DeliveryData{}
+		dd.Action = ISSUERS_ACTION
+		dd.Issuers = &issuers
+		return sendAdminRequest(*edKey, dd, reason, c.String("output"))
+	},
+}
+
+var Issuers = cli.Command{
+	Name:  "issuers",
+	Usage: "show the addresses that can add hashes",
+	Action: func(c *cli.Context) error {
+		issuers, err := IssuersRequest()
+		if err != nil {
+			return errors.New("Error: the query failed: " + err.Error())
+		}
+		if len(issuers) == 0 {
+			fmt.Println("Everyone can add hashes")
+			return nil
+		}
+		for _, v := range issuers {
+			fmt.Println(encodeAddress(v))
+		}
+		return nil
+	},
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli"
+)
+
+var Lease = cli.Command{
+	Name: "lease",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "receiver",
+			Usage: "the public key, the address or the @name of the lessee",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the hash of the file",
+		},
+		cli.Int64Flag{
+			Name:  "expiry",
+			Usage: "the block height that the hash returns to you",
+		},
+	},
+	Usage: "lend a hash to another person until a block height",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: the hash is empty")
+		}
+
+		receiver := c.String("receiver")
+		if len(receiver) == 0 {
+			return errors.New("Error: the receiver is empty")
+		}
+
+		expiry := c.Int64("expiry")
+		if expiry <= 0 {
+			return errors.New("Error: the expiry is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		b, err := receiverPublicKey(receiver)
+		if err != nil {
+			return err
+		}
+		_, err = LeaseRequest(*edKey, b, []string{hash}, expiry)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully leased the hash " + hash + " to " + receiver +
+			" until the block " + strconv.FormatInt(expiry, 10))
+		return nil
+	},
+}
@@ -14,6 +14,56 @@ func main() {
 		Add,
 		Remove,
 		Send,
+		SendMany,
+		SwapCreate,
+		SwapAccept,
+		HtlcLock,
+		HtlcClaim,
+		HtlcReclaim,
+		EscrowCreate,
+		EscrowRelease,
+		EscrowRefund,
+		MultisigCreate,
+		MultisigSend,
+		MultisigRemove,
+		Rotate,
+		Guardians,
+		Recovery,
+		RecoveryCancel,
+		Inheritance,
+		ClaimInheritance,
+		Lease,
+		Approve,
+		ApproveRevoke,
+		SendFrom,
+		RegisterSubKey,
+		RevokeSubKey,
+		Freeze,
+		Unfreeze,
+		ForcedTransfer,
+		Pause,
+		Unpause,
+		Ban,
+		Unban,
+		Register,
+		Unregister,
+		RegisterName,
+		TransferName,
+		Resolve,
+		Audit,
+		Batch,
+		Split,
+		Merge,
+		SetIssuers,
+		Issuers,
+		SchemaRegister,
+		SchemaUnregister,
+		Schemas,
+		ReconcilePins,
+		Alerts,
+		Monitor,
+		CoSign,
+		Broadcast,
 		Query,
 	}
 	err := app.Run(os.Args)
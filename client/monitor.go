@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+var Alerts = cli.Command{
+	Name: "alerts",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "webhook",
+			Usage: "the public https url that gets a POST when the hashes become unreachable",
+		},
+		cli.BoolFlag{
+			Name:  "unsubscribe",
+			Usage: "stop the alerts",
+		},
+	},
+	Usage: "subscribe to the alerts of the monitor for the hashes of the key",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		webhook := c.String("webhook")
+		if len(webhook) == 0 && !c.Bool("unsubscribe") {
+			return errors.New("Error: the webhook is missing")
+		}
+		if c.Bool("unsubscribe") {
+			webhook = ""
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = AlertsRequest(*edKey, webhook)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		if len(webhook) == 0 {
+			fmt.Println("Successfully unsubscribed from the alerts")
+			return nil
+		}
+		fmt.Println("Successfully subscribed " + webhook + " to the alerts")
+		return nil
+	},
+}
+
+var Monitor = cli.Command{
+	Name: "monitor",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "addr",
+			Usage: "the address of the owner, only for an admin or a member of the multisig",
+		},
+	},
+	Usage: "show the metrics of the monitor and your hashes that the IPFS does not return",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+		var owner *string
+		if addr := c.String("addr"); len(addr) > 0 {
+			addr, err := decodeAddress(addr)
+			if err != nil {
+				return err
+			}
+			owner = &addr
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		report, err := MonitorRequest(*edKey, owner)
+		if err != nil {
+			return errors.New("Error: the query failed: " + err.Error())
+		}
+		m := report.Metrics
+		fmt.Println("Rounds: " + strconv.FormatInt(m.Rounds, 10))
+		fmt.Println("Last round: " + time.Unix(m.LastRound, 0).UTC().Format(time.RFC3339) +
+			" in " + strconv.FormatInt(m.LastDuration, 10) + "ms")
+		fmt.Println("Available: " + strconv.Itoa(m.Available) + " of " + strconv.Itoa(m.Hashes))
+		fmt.Println("Alerts: " + strconv.FormatInt(m.AlertsSent, 10) + " sent, " +
+			strconv.FormatInt(m.AlertsFailed, 10) + " failed")
+		if len(report.Unavailable) > 0 {
+			fmt.Println("Unavailable:")
+			for _, v := range report.Unavailable {
+				fmt.Println(v.File + " of " + encodeAddress(v.Owner) + " since " +
+					time.Unix(v.Since, 0).UTC().Format(time.RFC3339))
+			}
+		}
+		return nil
+	},
+}
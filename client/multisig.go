@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+var MultisigCreate = cli.Command{
+	Name: "multisig-create",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of one of the members in json file",
+		},
+		cli.IntFlag{
+			Name:  "threshold",
+			Usage: "the number of signatures that the multisig needs",
+		},
+		cli.StringSliceFlag{
+			Name:  "member",
+			Usage: "the public key of a member, including yours, it can be repeated",
+		},
+	},
+	Usage: "create a multisig address that needs k of n keys to send or remove",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		threshold := c.Int("threshold")
+		if threshold <= 0 {
+			return errors.New("Error: the threshold is missing")
+		}
+
+		members := c.StringSlice("member")
+		if len(members) < threshold {
+			return errors.New("Error: the members are less than the threshold")
+		}
+		publicKeys := [][]byte{}
+		for _, v := range members {
+			b, err := decodeKey(v)
+			if err != nil {
+				return err
+			}
+			publicKeys = append(publicKeys, b)
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		addr, _, err := MultisigCreateRequest(*edKey, threshold, publicKeys)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully created the multisig address " + encodeAddress(addr))
+		return nil
+	},
+}
+
+var MultisigSend = cli.Command{
+	Name: "multisig-send",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of one of the members in json file",
+		},
+		cli.StringFlag{
+			Name:  "multisig",
+			Usage: "the multisig address that sends",
+		},
+		cli.StringFlag{
+			Name:  "receiver",
+			Usage: "the public key, the address or the @name of the receiver",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the hash of the file",
+		},
+		cli.StringFlag{
+			Name:  "output",
+			Usage: "the filename that the request will be saved for the other members to cosign",
+		},
+	},
+	Usage: "sign a send from a multisig address",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		multisig := c.String("multisig")
+		if len(multisig) == 0 {
+			return errors.New("Error: the multisig is missing")
+		}
+		multisig, err := decodeAddress(multisig)
+		if err != nil {
+			return err
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: the hash is empty")
+		}
+
+		receiver := c.String("receiver")
+		if len(receiver) == 0 {
+			return errors.New("Error: the receiver is empty")
+		}
+
+		output := c.String("output")
+		if len(output) == 0 {
+			return errors.New("Error: the output is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		b, err := receiverPublicKey(receiver)
+		if err != nil {
+			return err
+		}
+		dd := DeliveryData{}
+		dd.Action = SEND_ACTION
+		dd.To = &b
+		dd.Files = []string{hash}
+		dr := MultisigRequest(*edKey, multisig, dd)
+		err = writeRequest(output, dr)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Created successfully the request in " + output + ", the other members need to cosign it.")
+		return nil
+	},
+}
+
+var MultisigRemove = cli.Command{
+	Name: "multisig-remove",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of one of the members in json file",
+		},
+		cli.StringFlag{
+			Name:  "multisig",
+			Usage: "the multisig address that removes",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the hash of the file",
+		},
+		cli.StringFlag{
+			Name:  "output",
+			Usage: "the filename that the request will be saved for the other members to cosign",
+		},
+	},
+	Usage: "sign a remove from a multisig address",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		multisig := c.String("multisig")
+		if len(multisig) == 0 {
+			return errors.New("Error: the multisig is missing")
+		}
+		multisig, err := decodeAddress(multisig)
+		if err != nil {
+			return err
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: the hash is empty")
+		}
+
+		output := c.String("output")
+		if len(output) == 0 {
+			return errors.New("Error: the output is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		dd := DeliveryData{}
+		dd.Action = REMOVE_ACTION
+		dd.Files = []string{hash}
+		dr := MultisigRequest(*edKey, multisig, dd)
+		err = writeRequest(output, dr)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Created successfully the request in " + output + ", the other members need to cosign it.")
+		return nil
+	},
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+var RegisterName = cli.Command{
+	Name: "register-name",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "name",
+			Usage: "the name for the public key of the key",
+		},
+	},
+	Usage: "register a name that the others can use as @name for the receiver",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		name := c.String("name")
+		if len(name) == 0 {
+			return errors.New("Error: the name is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = RegisterNameRequest(*edKey, name)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully registered the name @" + name)
+		return nil
+	},
+}
+
+var TransferName = cli.Command{
+	Name: "transfer-name",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "name",
+			Usage: "the name that you own",
+		},
+		cli.StringFlag{
+			Name:  "receiver",
+			Usage: "the public key of the new owner",
+		},
+	},
+	Usage: "transfer a name to another public key",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		name := c.String("name")
+		if len(name) == 0 {
+			return errors.New("Error: the name is missing")
+		}
+
+		receiver := c.String("receiver")
+		if len(receiver) == 0 {
+			return errors.New("Error: the receiver is empty")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		b, err := receiverPublicKey(receiver)
+		if err != nil {
+			return err
+		}
+		_, err = TransferNameRequest(*edKey, name, b)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully transferred the name @" + name + " to " + receiver)
+		return nil
+	},
+}
+
+var Resolve = cli.Command{
+	Name: "resolve",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "name",
+			Usage: "the name without the '@'",
+		},
+	},
+	Usage: "show the public key of a name",
+	Action: func(c *cli.Context) error {
+		name := c.String("name")
+		if len(name) == 0 {
+			return errors.New("Error: the name is missing")
+		}
+
+		b, err := ResolveRequest(name)
+		if err != nil {
+			return errors.New("Error: the query failed: " + err.Error())
+		}
+		fmt.Println(encodePublicKey(b))
+		return nil
+	},
+}
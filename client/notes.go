@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+func noteCommand(action ActionStruct, usage string) cli.Command {
+	return cli.Command{
+		Name: string(action),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "the filename that contains the key in json file",
+			},
+			cli.StringSliceFlag{
+				Name:  "hash",
+				Usage: "the hash of a note that will be burned, it can be repeated",
+			},
+			cli.StringSliceFlag{
+				Name:  "new",
+				Usage: "the json of a new note like '{\"value\":2}', it can be repeated",
+			},
+			cli.StringFlag{
+				Name:  "schema",
+				Usage: "the hash of the registered json schema that the new notes follow",
+			},
+		},
+		Usage: usage,
+		Action: func(c *cli.Context) error {
+			key := c.String("key")
+			if len(key) == 0 {
+				return errors.New("Error: the key is missing")
+			}
+
+			hashes := c.StringSlice("hash")
+			if len(hashes) == 0 {
+				return errors.New("Error: the hash is empty")
+			}
+
+			newNotes := c.StringSlice("new")
+			if len(newNotes) == 0 {
+				return errors.New("Error: the new notes are missing")
+			}
+
+			edKey, err := fileKey(key)
+			if err != nil {
+				return err
+			}
+			newHashes := []string{}
+			for _, v := range newNotes {
+				hash, err := ipfsAddJson([]byte(v))
+				if err != nil {
+					return errors.New("Error: IPFS problem " + err.Error())
+				}
+				newHashes = append(newHashes, hash)
+			}
+			var schema *string
+			if s := c.String("schema"); len(s) > 0 {
+				schema = &s
+			}
+			_, err = NoteRequest(*edKey, action, hashes, newHashes, schema)
+			if err != nil {
+				return errors.New("Error: the transaction failed: " + err.Error())
+			}
+			fmt.Println("Successfully added the notes " + strings.Join(newHashes, ", "))
+			return nil
+		},
+	}
+}
+
+var Split = noteCommand(SPLIT_ACTION, "split a note into new notes with the same value")
+
+var Merge = noteCommand(MERGE_ACTION, "merge notes into a new note with the same value")
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli"
+)
+
+var ReconcilePins = cli.Command{
+	Name: "reconcile-pins",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "admin",
+			Value: "127.0.0.1:26661",
+			Usage: "the local address of the -pins-admin of the server",
+		},
+	},
+	Usage: "pin the hashes of the chain that the IPFS of the node lost and unpin the removed hashes, on the machine of the server",
+	Action: func(c *cli.Context) error {
+		report, err := ReconcilePinsRequest(c.String("admin"))
+		if err != nil {
+			return errors.New("Error: the reconciliation failed: " + err.Error())
+		}
+		fmt.Println("Pinned " + strconv.Itoa(len(report.Pinned)) + " and unpinned " +
+			strconv.Itoa(len(report.Unpinned)) + " hashes")
+		for _, v := range report.Pinned {
+			fmt.Println("Pinned " + v)
+		}
+		for _, v := range report.Unpinned {
+			fmt.Println("Unpinned " + v)
+		}
+		for _, v := range report.Failed {
+			fmt.Println("Failed " + v)
+		}
+		return nil
+	},
+}
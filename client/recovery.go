@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+var Guardians = cli.Command{
+	Name: "guardians",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringSliceFlag{
+			Name:  "guardian",
+			Usage: "the address of a guardian, it can be repeated",
+		},
+		cli.IntFlag{
+			Name:  "threshold",
+			Usage: "the number of guardians that need to sign the recovery",
+		},
+		cli.Int64Flag{
+			Name:  "delay",
+			Usage: "the number of blocks that you can cancel a recovery",
+		},
+	},
+	Usage: "register the guardians that can recover your hashes to a new key",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		guardians := c.StringSlice("guardian")
+		if len(guardians) == 0 {
+			return errors.New("Error: the guardians are missing")
+		}
+		guardians, err := decodeAddresses(guardians)
+		if err != nil {
+			return err
+		}
+
+		threshold := c.Int("threshold")
+		if threshold <= 0 {
+			return errors.New("Error: the threshold is missing")
+		}
+
+		delay := c.Int64("delay")
+		if delay <= 0 {
+			return errors.New("Error: the delay is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = GuardiansRequest(*edKey, guardians, threshold, delay)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully registered the guardians")
+		return nil
+	},
+}
+
+var Recovery = cli.Command{
+	Name: "recovery",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of a guardian in json file",
+		},
+		cli.StringFlag{
+			Name:  "account",
+			Usage: "the address of the account that will be recovered",
+		},
+		cli.StringFlag{
+			Name:  "new-key",
+			Usage: "the public key that will receive the hashes of the account",
+		},
+		cli.StringFlag{
+			Name:  "output",
+			Usage: "the filename that the request will be saved for the other guardians to cosign",
+		},
+	},
+	Usage: "sign the recovery of an account as a guardian",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		account := c.String("account")
+		if len(account) == 0 {
+			return errors.New("Error: the account is missing")
+		}
+		account, err := decodeAddress(account)
+		if err != nil {
+			return err
+		}
+
+		newKey := c.String("new-key")
+		if len(newKey) == 0 {
+			return errors.New("Error: the new key is missing")
+		}
+
+		output := c.String("output")
+		if len(output) == 0 {
+			return errors.New("Error: the output is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		b, err := decodeKey(newKey)
+		if err != nil {
+			return err
+		}
+		dr := RecoveryRequest(*edKey, account, b)
+		err = writeRequest(output, dr)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Created successfully the request in " + output + ", the other guardians need to cosign it.")
+		return nil
+	},
+}
+
+var RecoveryCancel = cli.Command{
+	Name: "recovery-cancel",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of the account in json file",
+		},
+	},
+	Usage: "cancel the recovery of your account",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = RecoveryCancelRequest(*edKey)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully canceled the recovery")
+		return nil
+	},
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+var Rotate = cli.Command{
+	Name: "rotate",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the old key in json file",
+		},
+		cli.StringFlag{
+			Name:  "new-key",
+			Usage: "the filename that contains the new key in json file",
+		},
+	},
+	Usage: "move all the hashes of the old key to the new key and retire the old key",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		newKey := c.String("new-key")
+		if len(newKey) == 0 {
+			return errors.New("Error: the new key is missing")
+		}
+
+		oldEdKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		newEdKey, err := fileKey(newKey)
+		if err != nil {
+			return err
+		}
+		_, err = RotateRequest(*oldEdKey, *newEdKey)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully rotated to the address " + encodeAddress(newEdKey.PubKey().Address().String()))
+		return nil
+	},
+}
@@ -30,8 +30,12 @@ func RpcBroadcastCommit(deliveryB []byte) (uint32, error) {
 }
 
 func RpcQuery(b []byte) ([]byte, uint32, error) {
+	return RpcQueryPath("", b)
+}
+
+func RpcQueryPath(path string, b []byte) ([]byte, uint32, error) {
 	cli := client.NewHTTP(Conf.AbciDaemon, "/websocket")
-	q, err := cli.ABCIQuery("", b)
+	q, err := cli.ABCIQuery(path, b)
 	if err != nil {
 		return nil, CodeTypeClientError, err
 	}
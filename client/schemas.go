@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+func schemaCommand(action ActionStruct, usage string) cli.Command {
+	return cli.Command{
+		Name: string(action),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "the filename that contains the key of the admin in json file",
+			},
+			cli.StringSliceFlag{
+				Name:  "hash",
+				Usage: "the hash of the json schema, it can be repeated",
+			},
+			cli.StringFlag{
+				Name:  "input",
+				Usage: "the json schema as a string json, it is added to the IPFS",
+			},
+			cli.StringFlag{
+				Name:  "reason",
+				Usage: "the reason for the audit log",
+			},
+			cli.StringFlag{
+				Name:  "output",
+				Usage: "the filename for the request when more admins need to cosign it",
+			},
+		},
+		Usage: usage,
+		Action: func(c *cli.Context) error {
+			key := c.String("key")
+			if len(key) == 0 {
+				return errors.New("Error: the key is missing")
+			}
+
+			reason := c.String("reason")
+			if len(reason) == 0 {
+				return errors.New("Error: the reason is missing")
+			}
+
+			dd := DeliveryData{}
+			dd.Action = action
+			dd.Files = c.StringSlice("hash")
+			if input := c.String("input"); len(input) > 0 {
+				hash, err := ipfsAddJson([]byte(input))
+				if err != nil {
+					return errors.New("Error: IPFS problem " + err.Error())
+				}
+				fmt.Println("The schema has the hash " + hash)
+				dd.Files = append(dd.Files, hash)
+			}
+			if len(dd.Files) == 0 {
+				return errors.New("Error: the hash or the input is missing")
+			}
+
+			edKey, err := fileKey(key)
+			if err != nil {
+				return err
+			}
+			return sendAdminRequest(*edKey, dd, reason, c.String("output"))
+		},
+	}
+}
+
+var SchemaRegister = schemaCommand(SCHEMA_REGISTER_ACTION, "register json schemas that the added json hashes follow")
+
+var SchemaUnregister = schemaCommand(SCHEMA_UNREGISTER_ACTION, "unregister json schemas, the hashes that follow them stay")
+
+var Schemas = cli.Command{
+	Name:  "schemas",
+	Usage: "show the hashes of the registered json schemas",
+	Action: func(c *cli.Context) error {
+		schemas, err := SchemasRequest()
+		if err != nil {
+			return errors.New("Error: the query failed: " + err.Error())
+		}
+		if len(schemas) == 0 {
+			fmt.Println("There are no registered schemas")
+			return nil
+		}
+		for _, v := range schemas {
+			fmt.Println(v)
+		}
+		return nil
+	},
+}
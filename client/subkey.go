@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/urfave/cli"
+)
+
+var RegisterSubKey = cli.Command{
+	Name: "subkey",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of the account in json file",
+		},
+		cli.StringFlag{
+			Name:  "subkey",
+			Usage: "the filename that contains the sub-key in json file",
+		},
+		cli.StringSliceFlag{
+			Name:  "action",
+			Usage: "the action that the sub-key can do, all the actions when it is empty",
+		},
+		cli.IntFlag{
+			Name:  "max-adds",
+			Usage: "the number of hashes that the sub-key can add",
+		},
+		cli.Int64Flag{
+			Name:  "expiry",
+			Usage: "the block height that the sub-key expires",
+		},
+	},
+	Usage: "register a sub-key that signs for your account, the sub-key file can be used as a key after that",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		subkey := c.String("subkey")
+		if len(subkey) == 0 {
+			return errors.New("Error: the sub-key is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(subkey)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		jk := JsonKey{}
+		err = json.Unmarshal(b, &jk)
+		if err != nil {
+			return errors.New("Error: json problem with the sub-key " + err.Error())
+		}
+		subPublicKey, err := decodeKey(jk.PublicKey)
+		if err != nil {
+			return errors.New("Error: decoding problem with the sub-key " + err.Error())
+		}
+
+		actions := []ActionStruct{}
+		for _, v := range c.StringSlice("action") {
+			actions = append(actions, ActionStruct(v))
+		}
+		_, err = SubKeyRequest(*edKey, subPublicKey, actions, c.Int("max-adds"), c.Int64("expiry"))
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+
+		// the sub-key file signs for the account from now on
+		jk.Account = edKey.PubKey().Address().String()
+		b, _ = json.Marshal(jk)
+		err = ioutil.WriteFile(subkey, b, 0644)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		fmt.Println("Successfully registered the sub-key " + jk.PublicAddr)
+		return nil
+	},
+}
+
+var RevokeSubKey = cli.Command{
+	Name: "subkey-revoke",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of the account in json file",
+		},
+		cli.StringFlag{
+			Name:  "subkey",
+			Usage: "the public key of the sub-key",
+		},
+	},
+	Usage: "revoke a sub-key of your account",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		subkey := c.String("subkey")
+		if len(subkey) == 0 {
+			return errors.New("Error: the sub-key is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		b, err := decodeKey(subkey)
+		if err != nil {
+			return err
+		}
+		_, err = SubKeyRevokeRequest(*edKey, b)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully revoked the sub-key " + subkey)
+		return nil
+	},
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/urfave/cli"
+)
+
+var SwapCreate = cli.Command{
+	Name: "swap-create",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key in json file",
+		},
+		cli.StringFlag{
+			Name:  "receiver",
+			Usage: "the public key or the @name of the receiver",
+		},
+		cli.StringSliceFlag{
+			Name:  "hash",
+			Usage: "the hash of the file that you give, it can be repeated",
+		},
+		cli.StringSliceFlag{
+			Name:  "receiver-hash",
+			Usage: "the hash of the file that the receiver gives, it can be repeated",
+		},
+		cli.StringFlag{
+			Name:  "output",
+			Usage: "the filename that the signed swap will be saved for the receiver",
+		},
+	},
+	Usage: "create and sign a swap that the receiver needs to accept",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		hashes := c.StringSlice("hash")
+		if len(hashes) == 0 {
+			return errors.New("Error: the hash is empty")
+		}
+
+		receiverHashes := c.StringSlice("receiver-hash")
+		if len(receiverHashes) == 0 {
+			return errors.New("Error: the receiver's hash is empty")
+		}
+
+		receiver := c.String("receiver")
+		if len(receiver) == 0 {
+			return errors.New("Error: the receiver is empty")
+		}
+
+		output := c.String("output")
+		if len(output) == 0 {
+			return errors.New("Error: the output is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		b, err := receiverPublicKey(receiver)
+		if err != nil {
+			return err
+		}
+		dr := SwapCreateRequest(*edKey, b, hashes, receiverHashes)
+		b, _ = json.Marshal(dr)
+		err = ioutil.WriteFile(output, b, 0644)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		fmt.Println("Created successfully the swap in " + output + ", give it to " + receiver + " to accept it.")
+		return nil
+	},
+}
+
+var SwapAccept = cli.Command{
+	Name: "swap-accept",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename that contains the key of the receiver in json file",
+		},
+		cli.StringFlag{
+			Name:  "input",
+			Usage: "the filename of the swap that the sender created",
+		},
+	},
+	Usage: "co-sign a swap and broadcast it",
+	Action: func(c *cli.Context) error {
+		key := c.String("key")
+		if len(key) == 0 {
+			return errors.New("Error: the key is missing")
+		}
+
+		input := c.String("input")
+		if len(input) == 0 {
+			return errors.New("Error: the input is missing")
+		}
+
+		edKey, err := fileKey(key)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(input)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		dr := DeliveryRequest{}
+		err = json.Unmarshal(b, &dr)
+		if err != nil {
+			return errors.New("Error: json problem with the swap " + err.Error())
+		}
+		fmt.Println("You give:")
+		for _, v := range dr.Data.ToFiles {
+			fmt.Println(v)
+		}
+		fmt.Println("You receive:")
+		for _, v := range dr.Data.Files {
+			fmt.Println(v)
+		}
+		_, err = SwapAcceptRequest(*edKey, dr)
+		if err != nil {
+			return errors.New("Error: the transaction failed: " + err.Error())
+		}
+		fmt.Println("Successfully swapped the hashes")
+		return nil
+	},
+}
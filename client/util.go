@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	shell "github.com/ipfs/go-ipfs-api"
@@ -44,10 +47,17 @@ func ipfsAddFolder(folder string) (string, error) {
 }
 
 func AddRequest(from crypto.PrivKeyEd25519, fileHashes []string) (uint32, error) {
+	return SchemaAddRequest(from, fileHashes, nil)
+}
+
+// SchemaAddRequest adds json hashes that follow the registered json schema.
+func SchemaAddRequest(from crypto.PrivKeyEd25519, fileHashes []string, schema *string) (uint32, error) {
 	dd := DeliveryData{}
 	dd.From = from.PubKey().Bytes()
 	dd.Action = ADD_ACTION
 	dd.Files = fileHashes
+	dd.Schema = schema
+	dd.FromAccount = fromAccount(from)
 	b, _ := json.Marshal(dd)
 	dr := DeliveryRequest{}
 	dr.Signature = from.Sign(b).Bytes()
@@ -61,6 +71,7 @@ func RemoveRequest(from crypto.PrivKeyEd25519, fileHashes []string) (uint32, err
 	dd.From = from.PubKey().Bytes()
 	dd.Action = REMOVE_ACTION
 	dd.Files = fileHashes
+	dd.FromAccount = fromAccount(from)
 	b, _ := json.Marshal(dd)
 	dr := DeliveryRequest{}
 	dr.Signature = from.Sign(b).Bytes()
@@ -75,6 +86,7 @@ func SendRequest(from crypto.PrivKeyEd25519, toPublicKey []byte, fileHashes []st
 	dd.Action = SEND_ACTION
 	dd.To = &toPublicKey
 	dd.Files = fileHashes
+	dd.FromAccount = fromAccount(from)
 	b, _ := json.Marshal(dd)
 	dr := DeliveryRequest{}
 	dr.Signature = from.Sign(b).Bytes()
@@ -83,7 +95,335 @@ func SendRequest(from crypto.PrivKeyEd25519, toPublicKey []byte, fileHashes []st
 	return RpcBroadcastCommit(b)
 }
 
-func SpbQueryRequest(from crypto.PrivKeyEd25519, file, userAddr *string) (*QueryResponse, uint32, error) {
+func VestingSendRequest(from crypto.PrivKeyEd25519, toPublicKey []byte, fileHashes []string,
+	vesting VestingLock) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = SEND_ACTION
+	dd.To = &toPublicKey
+	dd.Files = fileHashes
+	dd.Vesting = &vesting
+	return signAndBroadcast(from, dd)
+}
+
+// SwapCreateRequest builds and signs the sender's side of a swap,
+// the receiver needs to co-sign it with SwapAcceptRequest before it can be broadcasted.
+func SwapCreateRequest(from crypto.PrivKeyEd25519, toPublicKey []byte, fileHashes, toFileHashes []string) DeliveryRequest {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = SWAP_ACTION
+	dd.To = &toPublicKey
+	dd.Files = fileHashes
+	dd.ToFiles = toFileHashes
+	dd.FromAccount = fromAccount(from)
+	b, _ := json.Marshal(dd)
+	dr := DeliveryRequest{}
+	dr.Signature = from.Sign(b).Bytes()
+	dr.Data = dd
+	return dr
+}
+
+func SwapAcceptRequest(to crypto.PrivKeyEd25519, dr DeliveryRequest) (uint32, error) {
+	if dr.Data.To == nil || !bytes.Equal(*dr.Data.To, to.PubKey().Bytes()) {
+		return CodeTypeClientError, errors.New("The swap is not addressed to this key.")
+	}
+	b, _ := json.Marshal(dr.Data)
+	dr.CoSignature = to.Sign(b).Bytes()
+	b, _ = json.Marshal(dr)
+	return RpcBroadcastCommit(b)
+}
+
+func signRequest(from crypto.PrivKeyEd25519, dd DeliveryData) DeliveryRequest {
+	dd.FromAccount = fromAccount(from)
+	b, _ := json.Marshal(dd)
+	dr := DeliveryRequest{}
+	dr.Signature = from.Sign(b).Bytes()
+	dr.Data = dd
+	return dr
+}
+
+func signAndBroadcast(from crypto.PrivKeyEd25519, dd DeliveryData) (uint32, error) {
+	return BroadcastRequest(signRequest(from, dd))
+}
+
+func HtlcLockRequest(from crypto.PrivKeyEd25519, toPublicKey []byte, fileHashes []string,
+	secretHash []byte, timeout int64) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = HTLC_LOCK_ACTION
+	dd.To = &toPublicKey
+	dd.Files = fileHashes
+	dd.SecretHash = secretHash
+	dd.Timeout = timeout
+	return signAndBroadcast(from, dd)
+}
+
+func HtlcClaimRequest(from crypto.PrivKeyEd25519, fileHashes []string, secret []byte) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = HTLC_CLAIM_ACTION
+	dd.Files = fileHashes
+	dd.Secret = secret
+	return signAndBroadcast(from, dd)
+}
+
+func HtlcReclaimRequest(from crypto.PrivKeyEd25519, fileHashes []string) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = HTLC_RECLAIM_ACTION
+	dd.Files = fileHashes
+	return signAndBroadcast(from, dd)
+}
+
+func EscrowRequest(from crypto.PrivKeyEd25519, buyerPublicKey, arbiterPublicKey []byte, fileHashes []string) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = ESCROW_ACTION
+	dd.To = &buyerPublicKey
+	dd.Arbiter = &arbiterPublicKey
+	dd.Files = fileHashes
+	return signAndBroadcast(from, dd)
+}
+
+// EscrowSettleRequest signs the release or the refund of the files,
+// it needs the signature of a second party with CoSignRequest before it is broadcasted.
+func EscrowSettleRequest(from crypto.PrivKeyEd25519, action ActionStruct, fileHashes []string) DeliveryRequest {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = action
+	dd.Files = fileHashes
+	return signRequest(from, dd)
+}
+
+// CoSignRequest adds the signature of the key to a request that another key has created.
+func CoSignRequest(key crypto.PrivKeyEd25519, dr DeliveryRequest) DeliveryRequest {
+	b, _ := json.Marshal(dr.Data)
+	dr.Signatures = append(dr.Signatures, KeySignature{
+		PubKey:    key.PubKey().Bytes(),
+		Signature: key.Sign(b).Bytes(),
+	})
+	return dr
+}
+
+func BroadcastRequest(dr DeliveryRequest) (uint32, error) {
+	b, _ := json.Marshal(dr)
+	return RpcBroadcastCommit(b)
+}
+
+func readRequest(filename string) (*DeliveryRequest, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.New("Error: " + err.Error())
+	}
+	dr := DeliveryRequest{}
+	err = json.Unmarshal(b, &dr)
+	if err != nil {
+		return nil, errors.New("Error: json problem with the request " + err.Error())
+	}
+	return &dr, nil
+}
+
+func writeRequest(filename string, dr DeliveryRequest) error {
+	b, _ := json.Marshal(dr)
+	err := ioutil.WriteFile(filename, b, 0644)
+	if err != nil {
+		return errors.New("Error: " + err.Error())
+	}
+	return nil
+}
+
+func MultisigCreateRequest(from crypto.PrivKeyEd25519, threshold int, publicKeys [][]byte) (string, uint32, error) {
+	md := MultisigDefinition{
+		Threshold: threshold,
+		PubKeys:   publicKeys,
+	}
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = MULTISIG_CREATE_ACTION
+	dd.Multisig = &md
+	code, err := signAndBroadcast(from, dd)
+	return md.Address(), code, err
+}
+
+func SendToMultisigRequest(from crypto.PrivKeyEd25519, multisigAddr string, fileHashes []string) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = SEND_ACTION
+	dd.ToMultisig = &multisigAddr
+	dd.Files = fileHashes
+	return signAndBroadcast(from, dd)
+}
+
+// MultisigRequest signs a delivery for the multisig address,
+// the rest of the members add their signatures with CoSignRequest.
+func MultisigRequest(from crypto.PrivKeyEd25519, multisigAddr string, dd DeliveryData) DeliveryRequest {
+	dd.From = from.PubKey().Bytes()
+	dd.FromMultisig = &multisigAddr
+	return signRequest(from, dd)
+}
+
+// RotateRequest moves all the files of the old key to the new key
+// and retires the address of the old key, both keys sign it.
+func RotateRequest(oldKey, newKey crypto.PrivKeyEd25519) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = oldKey.PubKey().Bytes()
+	dd.Action = ROTATE_ACTION
+	toB := newKey.PubKey().Bytes()
+	dd.To = &toB
+	dr := signRequest(oldKey, dd)
+	b, _ := json.Marshal(dr.Data)
+	dr.CoSignature = newKey.Sign(b).Bytes()
+	return BroadcastRequest(dr)
+}
+
+func GuardiansRequest(from crypto.PrivKeyEd25519, guardians []string, threshold int, delay int64) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = GUARDIANS_ACTION
+	dd.Guardians = &GuardianSet{
+		Addresses: guardians,
+		Threshold: threshold,
+		Delay:     delay,
+	}
+	return signAndBroadcast(from, dd)
+}
+
+// RecoveryRequest signs the recovery of the account to the new public key,
+// the rest of the guardians add their signatures with CoSignRequest.
+func RecoveryRequest(guardian crypto.PrivKeyEd25519, account string, newPublicKey []byte) DeliveryRequest {
+	dd := DeliveryData{}
+	dd.From = guardian.PubKey().Bytes()
+	dd.Action = RECOVERY_ACTION
+	dd.LostAccount = &account
+	dd.To = &newPublicKey
+	return signRequest(guardian, dd)
+}
+
+func RecoveryCancelRequest(from crypto.PrivKeyEd25519) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = RECOVERY_CANCEL_ACTION
+	return signAndBroadcast(from, dd)
+}
+
+func InheritanceRequest(from crypto.PrivKeyEd25519, beneficiary string, period int64) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = INHERITANCE_ACTION
+	dd.Inheritance = &InheritancePlan{
+		Beneficiary: beneficiary,
+		Period:      period,
+	}
+	return signAndBroadcast(from, dd)
+}
+
+func ClaimInheritanceRequest(from crypto.PrivKeyEd25519, account string) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = CLAIM_INHERITANCE_ACTION
+	dd.Testator = &account
+	return signAndBroadcast(from, dd)
+}
+
+func LeaseRequest(from crypto.PrivKeyEd25519, toPublicKey []byte, fileHashes []string, expiry int64) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = LEASE_ACTION
+	dd.To = &toPublicKey
+	dd.Files = fileHashes
+	dd.LeaseExpiry = expiry
+	return signAndBroadcast(from, dd)
+}
+
+func ApproveRequest(from crypto.PrivKeyEd25519, delegate string, fileHashes []string,
+	count int, expiry int64) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = APPROVE_ACTION
+	dd.Files = fileHashes
+	dd.Approval = &Approval{Delegate: delegate, Count: count, Expiry: expiry}
+	return signAndBroadcast(from, dd)
+}
+
+func ApproveRevokeRequest(from crypto.PrivKeyEd25519, delegate string) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = APPROVE_REVOKE_ACTION
+	dd.Approval = &Approval{Delegate: delegate}
+	return signAndBroadcast(from, dd)
+}
+
+func SendFromRequest(from crypto.PrivKeyEd25519, account string, toPublicKey []byte,
+	fileHashes []string) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = SEND_FROM_ACTION
+	dd.Owner = &account
+	dd.To = &toPublicKey
+	dd.Files = fileHashes
+	return signAndBroadcast(from, dd)
+}
+
+// AdminRequest signs an admin action, the other admins can cosign it.
+func AdminRequest(from crypto.PrivKeyEd25519, dd DeliveryData, reason string) DeliveryRequest {
+	dd.From = from.PubKey().Bytes()
+	dd.Reason = &reason
+	return signRequest(from, dd)
+}
+
+func AuditRequest() ([]AuditEntry, error) {
+	resp, _, err := RpcQueryPath("/audit", nil)
+	if err != nil {
+		return nil, err
+	}
+	entries := []AuditEntry{}
+	json.Unmarshal(resp, &entries)
+	return entries, nil
+}
+
+func RegisterNameRequest(from crypto.PrivKeyEd25519, name string) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = REGISTER_NAME_ACTION
+	dd.Name = &name
+	return signAndBroadcast(from, dd)
+}
+
+func TransferNameRequest(from crypto.PrivKeyEd25519, name string, toPublicKey []byte) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = TRANSFER_NAME_ACTION
+	dd.Name = &name
+	dd.To = &toPublicKey
+	return signAndBroadcast(from, dd)
+}
+
+func ResolveRequest(name string) ([]byte, error) {
+	resp, _, err := RpcQueryPath("/resolve", []byte(name))
+	if err != nil {
+		return nil, err
+	}
+	nr := NameRecord{}
+	json.Unmarshal(resp, &nr)
+	return nr.PubKey, nil
+}
+
+// receiverPublicKey decodes the public key or the address (the PublicAddr of the key) of the receiver
+// from bech32 or hex,
+// a name that starts with '@' is resolved by the chain.
+func receiverPublicKey(receiver string) ([]byte, error) {
+	if strings.HasPrefix(receiver, "@") {
+		b, err := ResolveRequest(strings.TrimPrefix(receiver, "@"))
+		if err != nil {
+			return nil, errors.New("Error: the name could not be resolved: " + err.Error())
+		}
+		return b, nil
+	}
+	return decodeKey(receiver)
+}
+
+// signSpbQuery returns the query signed by the key, the server accepts it only for a few seconds.
+func signSpbQuery(from crypto.PrivKeyEd25519, file, userAddr *string) []byte {
 	q := SpbQuery{}
 	data := SpbQueryData{}
 	data.From = from.PubKey().Bytes()
@@ -99,7 +439,11 @@ func SpbQueryRequest(from crypto.PrivKeyEd25519, file, userAddr *string) (*Query
 	q.Data = data
 	q.Signature = from.Sign(b).Bytes()
 	b, _ = json.Marshal(q)
-	return query(b)
+	return b
+}
+
+func SpbQueryRequest(from crypto.PrivKeyEd25519, file, userAddr *string) (*QueryResponse, uint32, error) {
+	return query(signSpbQuery(from, file, userAddr))
 }
 
 func OtopbQueryRequest(from crypto.PrivKeyEd25519) (*QueryResponse, uint32, error) {
@@ -132,5 +476,123 @@ func fileKey(filename string) (*crypto.PrivKeyEd25519, error) {
 		return nil, errors.New("Error: private key decoding problem with the key " + err.Error())
 	}
 	key := edKey.(crypto.PrivKeyEd25519)
+	if len(jk.Account) > 0 {
+		subKeyAccounts[key.PubKey().Address().String()] = jk.Account
+	}
 	return &key, nil
 }
+
+// subKeyAccounts are the accounts of the sub-keys that have been read from files.
+var subKeyAccounts = map[string]string{}
+
+// fromAccount returns the account that the key signs for, when it is a sub-key.
+func fromAccount(key crypto.PrivKeyEd25519) *string {
+	account, ok := subKeyAccounts[key.PubKey().Address().String()]
+	if !ok {
+		return nil
+	}
+	return &account
+}
+
+func SubKeyRequest(from crypto.PrivKeyEd25519, subPublicKey []byte, actions []ActionStruct,
+	maxAdds int, expiry int64) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = SUBKEY_ACTION
+	dd.SubKey = &SubKey{PubKey: subPublicKey, Actions: actions, MaxAdds: maxAdds, Expiry: expiry}
+	return signAndBroadcast(from, dd)
+}
+
+func SubKeyRevokeRequest(from crypto.PrivKeyEd25519, subPublicKey []byte) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = SUBKEY_REVOKE_ACTION
+	dd.SubKey = &SubKey{PubKey: subPublicKey}
+	return signAndBroadcast(from, dd)
+}
+
+// BatchRequest signs the operations in one delivery, the chain applies all of them or none.
+func BatchRequest(from crypto.PrivKeyEd25519, operations []DeliveryData) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = BATCH_ACTION
+	dd.Operations = operations
+	return signAndBroadcast(from, dd)
+}
+
+// RecipientsSendRequest sends the files of each recipient in one delivery.
+func RecipientsSendRequest(from crypto.PrivKeyEd25519, recipients []Recipient) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = SEND_ACTION
+	dd.Recipients = recipients
+	return signAndBroadcast(from, dd)
+}
+
+// NoteRequest burns the notes of the From and gives it the new notes with the same value,
+// the action is the split or the merge.
+func NoteRequest(from crypto.PrivKeyEd25519, action ActionStruct, fileHashes, newFileHashes []string,
+	schema *string) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = action
+	dd.Files = fileHashes
+	dd.NewFiles = newFileHashes
+	dd.Schema = schema
+	return signAndBroadcast(from, dd)
+}
+
+func IssuersRequest() ([]string, error) {
+	resp, _, err := RpcQueryPath("/issuers", nil)
+	if err != nil {
+		return nil, err
+	}
+	issuers := []string{}
+	json.Unmarshal(resp, &issuers)
+	return issuers, nil
+}
+
+func SchemasRequest() ([]string, error) {
+	resp, _, err := RpcQueryPath("/schemas", nil)
+	if err != nil {
+		return nil, err
+	}
+	schemas := []string{}
+	json.Unmarshal(resp, &schemas)
+	return schemas, nil
+}
+
+// ReconcilePinsRequest asks the server on the admin address to reconcile its pins.
+func ReconcilePinsRequest(admin string) (*PinReport, error) {
+	resp, err := http.Post("http://"+admin+"/pins/reconcile", "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(strings.TrimSpace(string(b)))
+	}
+	report := PinReport{}
+	json.Unmarshal(b, &report)
+	return &report, nil
+}
+
+func AlertsRequest(from crypto.PrivKeyEd25519, webhook string) (uint32, error) {
+	dd := DeliveryData{}
+	dd.From = from.PubKey().Bytes()
+	dd.Action = ALERTS_ACTION
+	dd.Webhook = &webhook
+	return signAndBroadcast(from, dd)
+}
+
+// MonitorRequest returns the report of the monitor for the hashes of the key, or of the userAddr for an admin.
+func MonitorRequest(from crypto.PrivKeyEd25519, userAddr *string) (*MonitorReport, error) {
+	resp, _, err := RpcQueryPath("/monitor", signSpbQuery(from, nil, userAddr))
+	if err != nil {
+		return nil, err
+	}
+	report := MonitorReport{}
+	json.Unmarshal(resp, &report)
+	return &report, nil
+}
@@ -2,7 +2,7 @@ package conf
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
 
 	"github.com/ipfs/go-ipfs-api"
 )
@@ -20,6 +20,14 @@ type configuration struct {
 	WaitingSecondsQuery         int
 	AuthorizedAddressesIpfsHash string
 	authorizedAddresses         map[string]string
+	Permissioned                bool   // only the addresses that the admins registered can add, send and receive
+	Notes                       bool   // the json of the files are notes with a value that can be split and merged
+	Pin                         bool   // the hashes of the chain are pinned in the IPFS after each commit
+	PinsFile                    string // the file that keeps the hashes that the node pinned
+	PinsAddress                 string // the local address of the reconciliation of the pins, only for the operator
+	MonitorSeconds              int    // the seconds between the rounds of the monitor, 0 disables it
+	MonitorTimeoutSeconds       int    // the seconds that the IPFS has to return a hash for the monitor
+	MetricsAddress              string
 	AbciDaemon                  string
 }
 
@@ -27,24 +35,27 @@ func (c *configuration) GetAuthorizedAddresses() map[string]string {
 	return c.authorizedAddresses
 }
 
-func (c *configuration) SetAuthorizedAddresses() {
+// SetAuthorizedAddresses loads the addresses that can query the files of the other users
+// from the JSON list in the AuthorizedAddressesIpfsHash.
+func (c *configuration) SetAuthorizedAddresses() error {
 	sh := shell.NewShell(c.IpfsConnection)
 	if len(c.AuthorizedAddressesIpfsHash) == 0 {
-		return
+		return nil
 	}
 
 	listBy, err := sh.BlockGet(c.AuthorizedAddressesIpfsHash)
 	if err != nil {
-		log.Fatal("The ipfs hash for authorized addresses has a problem, " + err.Error())
+		return errors.New("The ipfs hash for authorized addresses has a problem, " + err.Error())
 	}
 	list := []string{}
 	err = json.Unmarshal(listBy, &list)
 	if err != nil {
-		log.Fatal("The ipfs hash is not a json")
+		return errors.New("The ipfs hash is not a json")
 	}
 	for _, v := range list {
 		c.authorizedAddresses[v] = v
 	}
+	return nil
 }
 
 var Conf = configuration{}
@@ -55,5 +66,4 @@ func init() {
 	Conf.Blockchain = SPB
 	Conf.WaitingSecondsQuery = 5
 	Conf.authorizedAddresses = map[string]string{}
-	Conf.SetAuthorizedAddresses()
 }
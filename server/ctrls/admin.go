@@ -0,0 +1,262 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+// isAdmin checks if the action is for the admins, they are accepted when the chain is paused.
+func (a ActionStruct) isAdmin() bool {
+	switch a {
+	case FREEZE_ACTION, UNFREEZE_ACTION, FORCED_TRANSFER_ACTION, PAUSE_ACTION, UNPAUSE_ACTION,
+		BAN_ACTION, UNBAN_ACTION, REGISTER_ACTION, UNREGISTER_ACTION, ISSUERS_ACTION,
+		SCHEMA_REGISTER_ACTION, SCHEMA_UNREGISTER_ACTION:
+		return true
+	}
+	return false
+}
+
+// getAdminSet returns the admins of the genesis, there are no admin actions when it is empty.
+func (pba *PBApplication) getAdminSet() AdminSet {
+	as := AdminSet{}
+	json.Unmarshal(pba.state.db.Get(adminsKey), &as)
+	return as
+}
+
+func (pba *PBApplication) setAdminSet(as AdminSet) {
+	if len(as.Addresses) == 0 {
+		pba.state.db.Delete(adminsKey)
+		return
+	}
+	b, _ := json.Marshal(as)
+	pba.state.db.Set(adminsKey, b)
+}
+
+func (as AdminSet) isAdmin(addr string) bool {
+	for _, v := range as.Addresses {
+		if v == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// adminSigners returns the admins that signed the delivery, in order.
+func (pba *PBApplication) adminSigners(dr DeliveryRequest) []string {
+	as := pba.getAdminSet()
+	admins := []string{}
+	for addr := range dr.SignerAddresses() {
+		if as.isAdmin(addr) {
+			admins = append(admins, addr)
+		}
+	}
+	sort.Strings(admins)
+	return admins
+}
+
+// adminValidation checks that the From is an admin with its own key,
+// that enough admins signed and that there is a reason for the audit log.
+func (pba *PBApplication) adminValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.FromMultisig != nil || dr.Data.FromAccount != nil {
+		return CodeTypeUnauthorized, errors.New("The admin needs to sign with its own key.")
+	}
+	fromAddr, _ := dr.FromPubKeyAddress()
+	as := pba.getAdminSet()
+	if !as.isAdmin(fromAddr) {
+		return CodeTypeUnauthorized, errors.New("You are not an admin.")
+	}
+	if len(pba.adminSigners(dr)) < as.Threshold {
+		return CodeTypeUnauthorized, errors.New("The admin action needs more admin signatures.")
+	}
+	if dr.Data.Reason == nil || len(*dr.Data.Reason) == 0 {
+		return CodeTypeUnauthorized, errors.New("The reason is missing.")
+	}
+	return CodeTypeOK, nil
+}
+
+// audit appends the admin action to the audit log.
+func (pba *PBApplication) audit(dr DeliveryRequest, toAddr string) {
+	var count int64
+	json.Unmarshal(pba.state.db.Get(auditCountKey), &count)
+	ae := AuditEntry{
+		Height: pba.currentHeight(),
+		Action: dr.Data.Action,
+		Admins: pba.adminSigners(dr),
+		Files:  dr.Data.Files,
+		To:     toAddr,
+		Reason: *dr.Data.Reason,
+	}
+	if dr.Data.Account != nil {
+		ae.Account = *dr.Data.Account
+	}
+	if dr.Data.Issuers != nil {
+		ae.Issuers = *dr.Data.Issuers
+	}
+	b, _ := json.Marshal(ae)
+	pba.state.db.Set(prefixAuditKey(count), b)
+	b, _ = json.Marshal(count + 1)
+	pba.state.db.Set(auditCountKey, b)
+}
+
+func (pba *PBApplication) auditLog() []AuditEntry {
+	entries := []AuditEntry{}
+	itr := dbm.IteratePrefix(pba.state.db, auditKey)
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		ae := AuditEntry{}
+		json.Unmarshal(itr.Value(), &ae)
+		entries = append(entries, ae)
+	}
+	return entries
+}
+
+// isFrozen checks if the file or its owner is frozen.
+func (pba *PBApplication) isFrozen(file string) bool {
+	if pba.state.db.Has(prefixFrozenKey(file)) {
+		return true
+	}
+	owner := pba.state.db.Get(prefixFileKey(file))
+	return len(owner) > 0 && pba.state.db.Has(prefixFrozenKey(string(owner)))
+}
+
+// frozenFilesValidation refuses the files that are frozen, every path that moves files needs it,
+// also the claims and the settlements of the files that are locked or in escrow.
+func (pba *PBApplication) frozenFilesValidation(files []string) (uint32, error) {
+	for _, v := range files {
+		if pba.isFrozen(v) {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is frozen.")
+		}
+	}
+	return CodeTypeOK, nil
+}
+
+// frozenAccountValidation refuses the moves of a whole account, like the recovery and the inheritance,
+// when the account or one of its files is frozen.
+func (pba *PBApplication) frozenAccountValidation(account string) (uint32, error) {
+	if pba.state.db.Has(prefixFrozenKey(account)) {
+		return CodeTypeUnauthorized, errors.New("The account " + account + " is frozen.")
+	}
+	return pba.frozenFilesValidation(pba.getUserFiles(account))
+}
+
+// freezeActionValidation checks the freeze and the unfreeze of the Files and the Account.
+func (pba *PBApplication) freezeActionValidation(dr DeliveryRequest) (uint32, error) {
+	code, err := pba.adminValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	targets := append([]string{}, dr.Data.Files...)
+	if dr.Data.Account != nil {
+		targets = append(targets, *dr.Data.Account)
+	}
+	if len(targets) == 0 {
+		return CodeTypeUnauthorized, errors.New("There is nothing to freeze.")
+	}
+	for _, v := range targets {
+		frozen := pba.state.db.Has(prefixFrozenKey(v))
+		if dr.Data.Action == FREEZE_ACTION && frozen {
+			return CodeTypeUnauthorized, errors.New("The " + v + " is already frozen.")
+		}
+		if dr.Data.Action == UNFREEZE_ACTION && !frozen {
+			return CodeTypeUnauthorized, errors.New("The " + v + " is not frozen.")
+		}
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) freezeActionState(dr DeliveryRequest) {
+	for _, v := range dr.Data.Files {
+		pba.state.db.Set(prefixFrozenKey(v), []byte(*dr.Data.Reason))
+	}
+	if dr.Data.Account != nil {
+		pba.state.db.Set(prefixFrozenKey(*dr.Data.Account), []byte(*dr.Data.Reason))
+	}
+	pba.audit(dr, "")
+}
+
+func (pba *PBApplication) unfreezeActionState(dr DeliveryRequest) {
+	for _, v := range dr.Data.Files {
+		pba.state.db.Delete(prefixFrozenKey(v))
+	}
+	if dr.Data.Account != nil {
+		pba.state.db.Delete(prefixFrozenKey(*dr.Data.Account))
+	}
+	pba.audit(dr, "")
+}
+
+// forcedTransferActionValidation checks that the Files of the Account can move to the receiver,
+// the freezes, the locks, the leases, the vestings and the escrows do not stop it.
+func (pba *PBApplication) forcedTransferActionValidation(dr DeliveryRequest) (uint32, error) {
+	code, err := pba.adminValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	if dr.Data.Account == nil {
+		return CodeTypeUnauthorized, errors.New("The account of the owner does not exists.")
+	}
+	account := *dr.Data.Account
+	if len(dr.Data.Files) == 0 {
+		return CodeTypeUnauthorized, errors.New("There are no files to transfer.")
+	}
+	for _, v := range dr.Data.Files {
+		if string(pba.state.db.Get(prefixFileKey(v))) != account {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is not owned by " + account + ".")
+		}
+	}
+	code, err = pba.receiverValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	toAddr, _ := dr.ToPubKeyAddress()
+	if toAddr == account {
+		return CodeTypeUnauthorized, errors.New("The receiver is the same as the account.")
+	}
+	if pba.state.db.Has(prefixRetiredKey(toAddr)) {
+		return CodeTypeUnauthorized, errors.New("The address of the receiver is retired.")
+	}
+	if conf.Conf.Blockchain == conf.OtoOPB && pba.state.db.Has(prefixUserKey(toAddr)) {
+		return CodeTypeUnauthorized, errors.New("The public key of the receiver exists in the DB.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) forcedTransferActionState(dr DeliveryRequest) {
+	toAddr, _ := dr.ToPubKeyAddress()
+	for _, v := range dr.Data.Files {
+		pba.state.db.Delete(prefixLockKey(v))
+		pba.state.db.Delete(prefixLeaseKey(v))
+		pba.state.db.Delete(prefixVestKey(v))
+		// the files in escrow leave it, so the parties can not settle them anymore
+		pba.state.db.Delete(prefixEscrowKey(v))
+	}
+	pba.transferFiles(*dr.Data.Account, toAddr, dr.Data.Files)
+	pba.audit(dr, toAddr)
+}
+
+func (pba *PBApplication) pauseActionValidation(dr DeliveryRequest) (uint32, error) {
+	code, err := pba.adminValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	paused := pba.state.db.Has(pausedKey)
+	if dr.Data.Action == PAUSE_ACTION && paused {
+		return CodeTypeUnauthorized, errors.New("The chain is already paused.")
+	}
+	if dr.Data.Action == UNPAUSE_ACTION && !paused {
+		return CodeTypeUnauthorized, errors.New("The chain is not paused.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) pauseActionState(dr DeliveryRequest) {
+	if dr.Data.Action == PAUSE_ACTION {
+		pba.state.db.Set(pausedKey, []byte(*dr.Data.Reason))
+	} else {
+		pba.state.db.Delete(pausedKey)
+	}
+	pba.audit(dr, "")
+}
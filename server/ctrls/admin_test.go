@@ -0,0 +1,596 @@
+package ctrls
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+// createAdmin starts the chain with a genesis that has a new key as its only admin.
+func (f forTestUtils) createAdmin(t *testing.T, pba *PBApplication) crypto.PrivKeyEd25519 {
+	adminEdKey := crypto.GenPrivKeyEd25519()
+	b, _ := json.Marshal(GenesisState{Admins: []string{adminEdKey.PubKey().Address().String()}})
+	pba.InitChain(types.RequestInitChain{AppStateBytes: b})
+	return adminEdKey
+}
+
+func TestSpbFreezeHashAndAddress(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	fromAddr := fromEdKey.PubKey().Address().String()
+
+	input := [][]byte{[]byte("random1"), []byte("random2")}
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// only the admins can freeze
+	freezeDr := utils.createDelivery(t, fromEdKey, DeliveryData{
+		Action: FREEZE_ACTION,
+		Reason: utils.str("the hash is stolen"),
+		Files:  addDr.Data.Files[:1],
+	})
+	b, _ = json.Marshal(freezeDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	freezeDr = utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action: FREEZE_ACTION,
+		Reason: utils.str("the hash is stolen"),
+		Files:  addDr.Data.Files[:1],
+	})
+	b, _ = json.Marshal(freezeDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr := utils.createSendDelivery(t, fromEdKey, &toEdKey, SEND_ACTION, addDr.Data.Files[:1])
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	sendDr = utils.createSendDelivery(t, fromEdKey, &toEdKey, SEND_ACTION, addDr.Data.Files[1:])
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// when the address is frozen, none of its hashes move
+	freezeDr = utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action: UNFREEZE_ACTION,
+		Reason: utils.str("the hash was found"),
+		Files:  addDr.Data.Files[:1],
+	})
+	b, _ = json.Marshal(freezeDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	freezeDr = utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action:  FREEZE_ACTION,
+		Reason:  utils.str("court order 123"),
+		Account: &fromAddr,
+	})
+	b, _ = json.Marshal(freezeDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, fromEdKey, REMOVE_ACTION, input[:1])
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	b, _ = json.Marshal([]AuditEntry{
+		{Height: 1, Action: FREEZE_ACTION, Admins: []string{adminEdKey.PubKey().Address().String()},
+			Files: addDr.Data.Files[:1], Reason: "the hash is stolen"},
+		{Height: 1, Action: UNFREEZE_ACTION, Admins: []string{adminEdKey.PubKey().Address().String()},
+			Files: addDr.Data.Files[:1], Reason: "the hash was found"},
+		{Height: 1, Action: FREEZE_ACTION, Admins: []string{adminEdKey.PubKey().Address().String()},
+			Account: fromAddr, Reason: "court order 123"},
+	})
+	assert.Equal(t, types.ResponseQuery{Code: CodeTypeOK, Value: b}, pba.Query(types.RequestQuery{Path: "/audit"}))
+}
+
+func TestSpbAdminsAndTheirThresholdComeFromTheGenesis(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	admins := []crypto.PrivKeyEd25519{crypto.GenPrivKeyEd25519(), crypto.GenPrivKeyEd25519()}
+	userEdKey := crypto.GenPrivKeyEd25519()
+
+	for _, v := range []GenesisState{
+		{Admins: []string{"not an address"}},
+		{Admins: utils.addresses(admins...), AdminThreshold: 3},
+		{Admins: utils.addresses(admins...), AdminThreshold: -1},
+		{AdminThreshold: 1},
+	} {
+		b, _ := json.Marshal(v)
+		assert.Panics(t, func() { pba.InitChain(types.RequestInitChain{AppStateBytes: b}) })
+	}
+	// an admin in lower case is the same address
+	b, _ := json.Marshal(GenesisState{
+		Admins:         []string{utils.address(admins[0]), strings.ToLower(utils.address(admins[1]))},
+		AdminThreshold: 2,
+	})
+	pba.InitChain(types.RequestInitChain{AppStateBytes: b})
+
+	addDr := utils.createAddOrRemoveDelivery(t, userEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	freeze := DeliveryData{
+		Action: FREEZE_ACTION,
+		Reason: utils.str("court order 123"),
+		Files:  addDr.Data.Files,
+	}
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the file", addDr, CodeTypeOK},
+		{"one admin is less than the threshold", utils.createDelivery(t, admins[0], freeze), CodeTypeUnauthorized},
+		{"a user does not count for the threshold", utils.createDelivery(t, admins[0], freeze, userEdKey), CodeTypeUnauthorized},
+		{"the user can not freeze with the admins", utils.createDelivery(t, userEdKey, freeze, admins...), CodeTypeUnauthorized},
+		{"the admins freeze together", utils.createDelivery(t, admins[1], freeze, admins[0]), CodeTypeOK},
+	})
+
+	signers := utils.addresses(admins...)
+	sort.Strings(signers)
+	b, _ = json.Marshal([]AuditEntry{
+		{Height: 1, Action: FREEZE_ACTION, Admins: signers, Files: addDr.Data.Files, Reason: "court order 123"},
+	})
+	assert.Equal(t, types.ResponseQuery{Code: CodeTypeOK, Value: b}, pba.Query(types.RequestQuery{Path: "/audit"}))
+}
+
+func TestSpbFreezeStopsEveryTransfer(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	arbiterEdKey := crypto.GenPrivKeyEd25519()
+	guardians := []crypto.PrivKeyEd25519{crypto.GenPrivKeyEd25519(), crypto.GenPrivKeyEd25519()}
+	secret := []byte("the secret")
+	secretHash := sha256.Sum256(secret)
+
+	addDr := utils.createAddOrRemoveDelivery(t, ownerEdKey, ADD_ACTION,
+		[][]byte{[]byte("random1"), []byte("random2"), []byte("random3")})
+	toAddDr := utils.createAddOrRemoveDelivery(t, toEdKey, ADD_ACTION, [][]byte{[]byte("random4")})
+	files := addDr.Data.Files
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files", addDr, CodeTypeOK},
+		{"add the files of the receiver", toAddDr, CodeTypeOK},
+		{"lock the hash for the receiver", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:     HTLC_LOCK_ACTION,
+			To:         utils.pubKey(toEdKey),
+			Files:      files[:1],
+			SecretHash: secretHash[:],
+			Timeout:    10,
+		}), CodeTypeOK},
+		{"put the hash in escrow", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:  ESCROW_ACTION,
+			To:      utils.pubKey(toEdKey),
+			Arbiter: utils.pubKey(arbiterEdKey),
+			Files:   files[1:2],
+		}), CodeTypeOK},
+		{"set the guardians", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:    GUARDIANS_ACTION,
+			Guardians: &GuardianSet{Addresses: utils.addresses(guardians...), Threshold: 1, Delay: 1},
+		}), CodeTypeOK},
+		{"set the beneficiary", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:      INHERITANCE_ACTION,
+			Inheritance: &InheritancePlan{Beneficiary: utils.address(toEdKey), Period: 1},
+		}), CodeTypeOK},
+		{"freeze the account and the hash in escrow", utils.createDelivery(t, adminEdKey, DeliveryData{
+			Action:  FREEZE_ACTION,
+			Reason:  utils.str("court order 123"),
+			Account: utils.str(utils.address(ownerEdKey)),
+			Files:   files[1:2],
+		}), CodeTypeOK},
+	})
+	pba.Commit()
+
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"a frozen hash can not be swapped", utils.coSign(utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:  SWAP_ACTION,
+			To:      utils.pubKey(toEdKey),
+			Files:   files[2:],
+			ToFiles: toAddDr.Data.Files,
+		}), toEdKey), CodeTypeUnauthorized},
+		{"the receiver can not claim a frozen hash", utils.createDelivery(t, toEdKey, DeliveryData{
+			Action: HTLC_CLAIM_ACTION,
+			Files:  files[:1],
+			Secret: secret,
+		}), CodeTypeUnauthorized},
+		{"the escrow can not release a frozen hash", utils.createDelivery(t, toEdKey, DeliveryData{
+			Action: ESCROW_RELEASE_ACTION,
+			Files:  files[1:2],
+		}, arbiterEdKey), CodeTypeUnauthorized},
+		{"the guardians can not recover a frozen account", utils.createDelivery(t, guardians[0], DeliveryData{
+			Action:      RECOVERY_ACTION,
+			LostAccount: utils.str(utils.address(ownerEdKey)),
+			To:          utils.pubKey(crypto.GenPrivKeyEd25519()),
+		}), CodeTypeUnauthorized},
+		{"the beneficiary can not claim a frozen account", utils.createDelivery(t, toEdKey, DeliveryData{
+			Action:   CLAIM_INHERITANCE_ACTION,
+			Testator: utils.str(utils.address(ownerEdKey)),
+		}), CodeTypeUnauthorized},
+	})
+}
+
+func TestSpbForcedTransfer(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	fromAddr := fromEdKey.PubKey().Address().String()
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	freezeDr := utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action:  FREEZE_ACTION,
+		Reason:  utils.str("court order 123"),
+		Account: &fromAddr,
+	})
+	b, _ = json.Marshal(freezeDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	toB := toEdKey.PubKey().Bytes()
+	forcedDr := utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action:  FORCED_TRANSFER_ACTION,
+		Reason:  utils.str(""),
+		Account: &fromAddr,
+		To:      &toB,
+		Files:   addDr.Data.Files,
+	})
+	b, _ = json.Marshal(forcedDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	forcedDr = utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action:  FORCED_TRANSFER_ACTION,
+		Reason:  utils.str("court order 123"),
+		Account: &fromAddr,
+		To:      &toB,
+		Files:   addDr.Data.Files,
+	})
+	b, _ = json.Marshal(forcedDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, toEdKey, REMOVE_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbForcedTransferClosesTheEscrow(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	sellerEdKey := crypto.GenPrivKeyEd25519()
+	buyerEdKey := crypto.GenPrivKeyEd25519()
+	arbiterEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, sellerEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files", addDr, CodeTypeOK},
+		{"put the files in escrow", utils.createDelivery(t, sellerEdKey, DeliveryData{
+			Action:  ESCROW_ACTION,
+			To:      utils.pubKey(buyerEdKey),
+			Arbiter: utils.pubKey(arbiterEdKey),
+			Files:   addDr.Data.Files,
+		}), CodeTypeOK},
+		{"transfer the files out of the escrow", utils.createDelivery(t, adminEdKey, DeliveryData{
+			Action:  FORCED_TRANSFER_ACTION,
+			Reason:  utils.str("court order 123"),
+			Account: utils.str(escrowHolder),
+			To:      utils.pubKey(toEdKey),
+			Files:   addDr.Data.Files,
+		}), CodeTypeOK},
+		{"the parties can not release the files anymore", utils.createDelivery(t, buyerEdKey, DeliveryData{
+			Action: ESCROW_RELEASE_ACTION,
+			Files:  addDr.Data.Files,
+		}, arbiterEdKey), CodeTypeUnauthorized},
+	})
+	assert.Nil(t, pba.getEscrow(addDr.Data.Files[0]))
+	assert.Equal(t, addDr.Data.Files, pba.getUserFiles(utils.address(toEdKey)))
+}
+
+func TestSpbPause(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	conf.Conf.WaitingSecondsQuery = 5
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	pauseDr := utils.createDelivery(t, adminEdKey, DeliveryData{Action: PAUSE_ACTION, Reason: utils.str("ipfs outage")})
+	b, _ = json.Marshal(pauseDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr := utils.createSendDelivery(t, fromEdKey, &toEdKey, SEND_ACTION, addDr.Data.Files)
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypePaused, pba.CheckTx(b).Code)
+	assert.Equal(t, CodeTypePaused, pba.DeliverTx(b).Code)
+
+	// the queries keep working
+	q := utils.querySpb(t, fromEdKey, nil, nil)
+	qb, _ := json.Marshal(q)
+	assert.Equal(t, CodeTypeOK, pba.Query(types.RequestQuery{Data: qb}).Code)
+
+	pauseDr = utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action: UNPAUSE_ACTION,
+		Reason: utils.str("ipfs is back"),
+	})
+	pb, _ := json.Marshal(pauseDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(pb).Code)
+
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbBanHashes(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	banned := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	banDr := utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action: BAN_ACTION,
+		Reason: utils.str("counterfeit note template"),
+		Files:  append(addDr.Data.Files, banned.Data.Files...),
+	})
+	b, _ = json.Marshal(banDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the banned hashes can not be added or sent
+	b, _ = json.Marshal(banned)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	sendDr := utils.createSendDelivery(t, fromEdKey, &toEdKey, SEND_ACTION, addDr.Data.Files)
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestSpbBanHashesStopsEveryTransfer(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	delegateEdKey := crypto.GenPrivKeyEd25519()
+	arbiterEdKey := crypto.GenPrivKeyEd25519()
+	secret := []byte("the secret")
+	secretHash := sha256.Sum256(secret)
+
+	addDr := utils.createAddOrRemoveDelivery(t, ownerEdKey, ADD_ACTION,
+		[][]byte{[]byte("random1"), []byte("random2"), []byte("random3"), []byte("random4")})
+	toAddDr := utils.createAddOrRemoveDelivery(t, toEdKey, ADD_ACTION, [][]byte{[]byte("random5")})
+	files := addDr.Data.Files
+	banDr := utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action: BAN_ACTION,
+		Reason: utils.str("counterfeit note template"),
+		Files:  files,
+	})
+	claimDr := utils.createDelivery(t, toEdKey, DeliveryData{
+		Action: HTLC_CLAIM_ACTION,
+		Files:  files[1:2],
+		Secret: secret,
+	})
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files", addDr, CodeTypeOK},
+		{"add the files of the receiver", toAddDr, CodeTypeOK},
+		{"approve the delegate", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:   APPROVE_ACTION,
+			Approval: &Approval{Delegate: utils.address(delegateEdKey), Count: 1},
+		}), CodeTypeOK},
+		{"lock the hash for the receiver", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:     HTLC_LOCK_ACTION,
+			To:         utils.pubKey(toEdKey),
+			Files:      files[1:2],
+			SecretHash: secretHash[:],
+			Timeout:    10,
+		}), CodeTypeOK},
+		{"put the hash in escrow", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:  ESCROW_ACTION,
+			To:      utils.pubKey(toEdKey),
+			Arbiter: utils.pubKey(arbiterEdKey),
+			Files:   files[2:3],
+		}), CodeTypeOK},
+		{"ban the hashes", banDr, CodeTypeOK},
+		{"the delegate can not send a banned hash", utils.createDelivery(t, delegateEdKey, DeliveryData{
+			Action: SEND_FROM_ACTION,
+			Owner:  utils.str(utils.address(ownerEdKey)),
+			To:     utils.pubKey(toEdKey),
+			Files:  files[:1],
+		}), CodeTypeUnauthorized},
+		{"the receiver can not claim a banned hash", claimDr, CodeTypeUnauthorized},
+		{"the escrow can not release a banned hash", utils.createDelivery(t, toEdKey, DeliveryData{
+			Action: ESCROW_RELEASE_ACTION,
+			Files:  files[2:3],
+		}, arbiterEdKey), CodeTypeUnauthorized},
+		{"a banned hash can not be swapped", utils.coSign(utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:  SWAP_ACTION,
+			To:      utils.pubKey(toEdKey),
+			Files:   files[3:],
+			ToFiles: toAddDr.Data.Files,
+		}), toEdKey), CodeTypeUnauthorized},
+		{"unban the hashes", utils.createDelivery(t, adminEdKey, DeliveryData{
+			Action: UNBAN_ACTION,
+			Reason: utils.str("the content was reviewed"),
+			Files:  files,
+		}), CodeTypeOK},
+		{"the receiver claims the unbanned hash", claimDr, CodeTypeOK},
+	})
+}
+
+func TestSpbBanHashesWithForceRemove(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	fromEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	banDr := utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action:      BAN_ACTION,
+		Reason:      utils.str("illegal content"),
+		Files:       addDr.Data.Files,
+		ForceRemove: true,
+	})
+	b, _ = json.Marshal(banDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	assert.False(t, pba.state.db.Has(prefixFileKey(addDr.Data.Files[0])))
+	assert.Equal(t, []string{}, pba.getUserFiles(fromEdKey.PubKey().Address().String()))
+
+	// after the unban the hash can be added again
+	banDr = utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action: UNBAN_ACTION,
+		Reason: utils.str("the content was reviewed"),
+		Files:  addDr.Data.Files,
+	})
+	b, _ = json.Marshal(banDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbPermissioned(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	conf.Conf.Permissioned = true
+	defer func() { conf.Conf.Permissioned = false }()
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	fromAddr := fromEdKey.PubKey().Address().String()
+	toAddr := toEdKey.PubKey().Address().String()
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	registerDr := utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action:  REGISTER_ACTION,
+		Reason:  utils.str("member of the consortium"),
+		Account: &fromAddr,
+	})
+	b, _ = json.Marshal(registerDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the receiver is not registered yet
+	sendDr := utils.createSendDelivery(t, fromEdKey, &toEdKey, SEND_ACTION, addDr.Data.Files)
+	sb, _ := json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(sb).Code)
+
+	registerDr = utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action:  REGISTER_ACTION,
+		Reason:  utils.str("member of the consortium"),
+		Account: &toAddr,
+	})
+	b, _ = json.Marshal(registerDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(sb).Code)
+}
+
+func TestSpbPermissionedOnEveryPath(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	conf.Conf.Permissioned = true
+	defer func() { conf.Conf.Permissioned = false }()
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	arbiterEdKey := crypto.GenPrivKeyEd25519()
+	guardianEdKey := crypto.GenPrivKeyEd25519()
+	secret := []byte("the secret")
+	secretHash := sha256.Sum256(secret)
+	register := func(action ActionStruct, key crypto.PrivKeyEd25519) DeliveryRequest {
+		return utils.createDelivery(t, adminEdKey, DeliveryData{
+			Action:  action,
+			Reason:  utils.str("member of the consortium"),
+			Account: utils.str(utils.address(key)),
+		})
+	}
+
+	addDr := utils.createAddOrRemoveDelivery(t, ownerEdKey, ADD_ACTION,
+		[][]byte{[]byte("random1"), []byte("random2"), []byte("random3")})
+	toAddDr := utils.createAddOrRemoveDelivery(t, toEdKey, ADD_ACTION, [][]byte{[]byte("random4")})
+	files := addDr.Data.Files
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"register the owner", register(REGISTER_ACTION, ownerEdKey), CodeTypeOK},
+		{"register the receiver", register(REGISTER_ACTION, toEdKey), CodeTypeOK},
+		{"add the files", addDr, CodeTypeOK},
+		{"add the files of the receiver", toAddDr, CodeTypeOK},
+		{"lock the hash for the receiver", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:     HTLC_LOCK_ACTION,
+			To:         utils.pubKey(toEdKey),
+			Files:      files[:1],
+			SecretHash: secretHash[:],
+			Timeout:    10,
+		}), CodeTypeOK},
+		{"put the hash in escrow", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:  ESCROW_ACTION,
+			To:      utils.pubKey(toEdKey),
+			Arbiter: utils.pubKey(arbiterEdKey),
+			Files:   files[1:2],
+		}), CodeTypeOK},
+		{"set the guardians", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:    GUARDIANS_ACTION,
+			Guardians: &GuardianSet{Addresses: utils.addresses(guardianEdKey), Threshold: 1, Delay: 1},
+		}), CodeTypeOK},
+		{"unregister the receiver", register(UNREGISTER_ACTION, toEdKey), CodeTypeOK},
+		{"the unregistered receiver can not claim", utils.createDelivery(t, toEdKey, DeliveryData{
+			Action: HTLC_CLAIM_ACTION,
+			Files:  files[:1],
+			Secret: secret,
+		}), CodeTypeUnauthorized},
+		{"the escrow can not release to the unregistered buyer", utils.createDelivery(t, toEdKey, DeliveryData{
+			Action: ESCROW_RELEASE_ACTION,
+			Files:  files[1:2],
+		}, arbiterEdKey), CodeTypeUnauthorized},
+		{"the unregistered party can not swap", utils.coSign(utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:  SWAP_ACTION,
+			To:      utils.pubKey(toEdKey),
+			Files:   files[2:],
+			ToFiles: toAddDr.Data.Files,
+		}), toEdKey), CodeTypeUnauthorized},
+		{"the account can not be recovered to an unregistered key", utils.createDelivery(t, guardianEdKey, DeliveryData{
+			Action:      RECOVERY_ACTION,
+			LostAccount: utils.str(utils.address(ownerEdKey)),
+			To:          utils.pubKey(crypto.GenPrivKeyEd25519()),
+		}), CodeTypeUnauthorized},
+		{"the beneficiary needs to be registered", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:      INHERITANCE_ACTION,
+			Inheritance: &InheritancePlan{Beneficiary: utils.address(toEdKey), Period: 1},
+		}), CodeTypeUnauthorized},
+		{"register the receiver again", register(REGISTER_ACTION, toEdKey), CodeTypeOK},
+		{"the registered receiver claims", utils.createDelivery(t, toEdKey, DeliveryData{
+			Action: HTLC_CLAIM_ACTION,
+			Files:  files[:1],
+			Secret: secret,
+		}), CodeTypeOK},
+	})
+}
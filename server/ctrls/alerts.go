@@ -0,0 +1,86 @@
+package ctrls
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+const maxWebhookLength = 256
+
+// privateNetworks are the addresses that a webhook can not have,
+// so the alerts of the node can not reach the services of its own network.
+var privateNetworks = func() []*net.IPNet {
+	nets := []*net.IPNet{}
+	for _, v := range []string{"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+		"172.16.0.0/12", "192.168.0.0/16", "::/128", "::1/128", "fc00::/7", "fe80::/10"} {
+		_, n, _ := net.ParseCIDR(v)
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsMulticast() {
+		return false
+	}
+	for _, n := range privateNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// getWebhook returns the url that the owner subscribed for the alerts of the monitor.
+func (pba *PBApplication) getWebhook(addr string) string {
+	return string(pba.state.db.Get(prefixAlertKey(addr)))
+}
+
+// getWebhooks returns the webhooks of all the owners.
+func (pba *PBApplication) getWebhooks() map[string]string {
+	webhooks := map[string]string{}
+	itr := dbm.IteratePrefix(pba.state.db, alertKey)
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		webhooks[string(itr.Key()[len(alertKey):])] = string(itr.Value())
+	}
+	return webhooks
+}
+
+func (pba *PBApplication) alertsActionValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.Webhook == nil {
+		return CodeTypeUnauthorized, errors.New("The webhook does not exists.")
+	}
+	// the empty webhook unsubscribes
+	if len(*dr.Data.Webhook) == 0 {
+		return CodeTypeOK, nil
+	}
+	if len(*dr.Data.Webhook) > maxWebhookLength {
+		return CodeTypeEncodingError, errors.New("The webhook is longer than 256 characters.")
+	}
+	u, err := url.Parse(*dr.Data.Webhook)
+	if err != nil || u.Scheme != "https" || len(u.Hostname()) == 0 {
+		return CodeTypeEncodingError, errors.New("The webhook needs to be an https url.")
+	}
+	// the names are not resolved here, because the nodes could get other addresses,
+	// the monitor checks the address of the name when it sends the alert
+	host := strings.ToLower(u.Hostname())
+	ip := net.ParseIP(host)
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || (ip != nil && !isPublicIP(ip)) {
+		return CodeTypeEncodingError, errors.New("The webhook can not be a local or a private address.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) alertsActionState(dr DeliveryRequest) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	if len(*dr.Data.Webhook) == 0 {
+		pba.state.db.Delete(prefixAlertKey(fromAddr))
+		return
+	}
+	pba.state.db.Set(prefixAlertKey(fromAddr), []byte(*dr.Data.Webhook))
+}
@@ -1,6 +1,8 @@
 package ctrls
 
 import (
+	"sync"
+
 	"github.com/tendermint/abci/types"
 	dbm "github.com/tendermint/tmlibs/db"
 )
@@ -11,6 +13,13 @@ type PBApplication struct {
 	types.BaseApplication
 
 	state State
+
+	pinner  *Pinner  // only when the pinning is enabled
+	touched []string // the hashes that the deliveries of the block changed, for the pinner
+	monitor *Monitor // only when the monitor is enabled
+
+	mtx       sync.Mutex
+	committed committedState // the state of the last commit, only when the pinning or the monitor is enabled
 }
 
 func NewPBApplication() *PBApplication {
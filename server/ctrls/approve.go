@@ -0,0 +1,208 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+func (pba *PBApplication) getApproval(owner, delegate string) *Approval {
+	b := pba.state.db.Get(prefixApproveKey(owner, delegate))
+	if len(b) == 0 {
+		return nil
+	}
+	a := Approval{}
+	json.Unmarshal(b, &a)
+	return &a
+}
+
+func (a *Approval) isActive(height int64) bool {
+	return a.Expiry == 0 || height < a.Expiry
+}
+
+func (a *Approval) allows(file string) bool {
+	if len(a.Files) == 0 {
+		return true
+	}
+	for _, v := range a.Files {
+		if v == file {
+			return true
+		}
+	}
+	return false
+}
+
+// approvals returns the active approvals that the owner has given.
+func (pba *PBApplication) approvals(owner string) []Approval {
+	as := []Approval{}
+	itr := dbm.IteratePrefix(pba.state.db, prefixApproveKey(owner, ""))
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		a := Approval{}
+		json.Unmarshal(itr.Value(), &a)
+		if a.isActive(pba.currentHeight()) {
+			as = append(as, a)
+		}
+	}
+	return as
+}
+
+// moveApprovals gives the approvals of the old address to the new one,
+// both the approvals that it has given and the approvals that it has as a delegate.
+func (pba *PBApplication) moveApprovals(oldAddr, newAddr string) {
+	keys := [][]byte{}
+	owners := []string{}
+	approvals := []Approval{}
+	itr := dbm.IteratePrefix(pba.state.db, approveKey)
+	for ; itr.Valid(); itr.Next() {
+		parts := strings.SplitN(string(itr.Key()[len(approveKey):]), ":", 2)
+		owner, delegate := parts[0], parts[1]
+		if owner != oldAddr && delegate != oldAddr {
+			continue
+		}
+		keys = append(keys, itr.Key())
+		if owner == oldAddr {
+			owner = newAddr
+		}
+		a := Approval{}
+		json.Unmarshal(itr.Value(), &a)
+		if delegate == oldAddr {
+			a.Delegate = newAddr
+		}
+		owners = append(owners, owner)
+		approvals = append(approvals, a)
+	}
+	itr.Close()
+
+	for _, k := range keys {
+		pba.state.db.Delete(k)
+	}
+	for i, owner := range owners {
+		// the new address can not be its own delegate
+		if owner == approvals[i].Delegate {
+			continue
+		}
+		b, _ := json.Marshal(approvals[i])
+		pba.state.db.Set(prefixApproveKey(owner, approvals[i].Delegate), b)
+	}
+}
+
+// approveActionValidation checks the approval of the From,
+// an approval without Count and Expiry has no limit.
+func (pba *PBApplication) approveActionValidation(dr DeliveryRequest) (uint32, error) {
+	a := dr.Data.Approval
+	if a == nil {
+		return CodeTypeUnauthorized, errors.New("The approval does not exists.")
+	}
+	fromAddr, _ := dr.FromPubKeyAddress()
+	if len(a.Delegate) == 0 || a.Delegate == fromAddr {
+		return CodeTypeUnauthorized, errors.New("The delegate needs to be another address.")
+	}
+	if a.Count < 0 {
+		return CodeTypeUnauthorized, errors.New("The count can not be negative.")
+	}
+	if a.Expiry != 0 && a.Expiry <= pba.currentHeight() {
+		return CodeTypeUnauthorized, errors.New("The expiry needs to be after the current block height.")
+	}
+	for _, v := range dr.Data.Files {
+		if string(pba.state.db.Get(prefixFileKey(v))) != fromAddr {
+			return CodeTypeUnauthorized, errors.New("You dont own The hash " + v + ".")
+		}
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) approveActionState(dr DeliveryRequest) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	a := *dr.Data.Approval
+	a.Files = dr.Data.Files
+	b, _ := json.Marshal(a)
+	pba.state.db.Set(prefixApproveKey(fromAddr, a.Delegate), b)
+}
+
+// approveRevokeActionValidation checks that the From has given an approval to the Delegate.
+func (pba *PBApplication) approveRevokeActionValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.Approval == nil {
+		return CodeTypeUnauthorized, errors.New("The approval does not exists.")
+	}
+	fromAddr, _ := dr.FromPubKeyAddress()
+	if pba.getApproval(fromAddr, dr.Data.Approval.Delegate) == nil {
+		return CodeTypeUnauthorized, errors.New("You have not approved the delegate.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) approveRevokeActionState(dr DeliveryRequest) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	pba.state.db.Delete(prefixApproveKey(fromAddr, dr.Data.Approval.Delegate))
+}
+
+// sendFromActionValidation checks that the From, the delegate, has an active approval
+// for the Files of the Owner and that they can be sent like on a send.
+func (pba *PBApplication) sendFromActionValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.Owner == nil {
+		return CodeTypeUnauthorized, errors.New("The address of the owner does not exists.")
+	}
+	account := *dr.Data.Owner
+	delegate, _ := dr.FromPubKeyAddress()
+	a := pba.getApproval(account, delegate)
+	if a == nil || !a.isActive(pba.currentHeight()) {
+		return CodeTypeUnauthorized, errors.New("You are not approved to send from " + account + ".")
+	}
+	if a.Count > 0 && len(dr.Data.Files) > a.Count {
+		return CodeTypeUnauthorized, errors.New("The approval allows only " + strconv.Itoa(a.Count) + " more hashes.")
+	}
+	for _, v := range dr.Data.Files {
+		if !a.allows(v) {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is not approved.")
+		}
+	}
+	code, err := pba.receiverValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	toAddr, _ := dr.ToPubKeyAddress()
+	return pba.transferValidation(account, toAddr, dr.Data.Files)
+}
+
+// sendFromActionState sends the files and uses up the approval.
+func (pba *PBApplication) sendFromActionState(dr DeliveryRequest) {
+	account := *dr.Data.Owner
+	delegate, _ := dr.FromPubKeyAddress()
+	toAddr, _ := dr.ToPubKeyAddress()
+	pba.transferFiles(account, toAddr, dr.Data.Files)
+
+	a := pba.getApproval(account, delegate)
+	if len(a.Files) > 0 {
+		files := []string{}
+		for _, v := range a.Files {
+			sent := false
+			for _, s := range dr.Data.Files {
+				if s == v {
+					sent = true
+					break
+				}
+			}
+			if !sent {
+				files = append(files, v)
+			}
+		}
+		if len(files) == 0 {
+			pba.state.db.Delete(prefixApproveKey(account, delegate))
+			return
+		}
+		a.Files = files
+	}
+	if a.Count > 0 {
+		a.Count -= len(dr.Data.Files)
+		if a.Count == 0 {
+			pba.state.db.Delete(prefixApproveKey(account, delegate))
+			return
+		}
+	}
+	b, _ := json.Marshal(a)
+	pba.state.db.Set(prefixApproveKey(account, delegate), b)
+}
@@ -0,0 +1,162 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbSendFromUpToTheCount(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	delegateEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	ownerAddr := ownerEdKey.PubKey().Address().String()
+
+	addDr := utils.createAddOrRemoveDelivery(t, ownerEdKey, ADD_ACTION,
+		[][]byte{[]byte("random1"), []byte("random2")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	a := Approval{Delegate: delegateEdKey.PubKey().Address().String(), Count: 1}
+	approveDr := utils.createDelivery(t, ownerEdKey, DeliveryData{Action: APPROVE_ACTION, Approval: &a})
+	b, _ = json.Marshal(approveDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the owner sees its approval
+	q := utils.querySpb(t, ownerEdKey, nil, nil)
+	b, _ = json.Marshal(q)
+	qr := QueryResponse{}
+	json.Unmarshal(pba.Query(types.RequestQuery{Data: b}).Value, &qr)
+	assert.Equal(t, []Approval{a}, qr.Approvals)
+
+	// the Account is only for the admin actions
+	sendDr := utils.createDelivery(t, delegateEdKey, DeliveryData{
+		Action:  SEND_FROM_ACTION,
+		Account: &ownerAddr,
+		To:      utils.pubKey(toEdKey),
+		Files:   addDr.Data.Files[:1],
+	})
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	sendDr = utils.createDelivery(t, delegateEdKey, DeliveryData{
+		Action: SEND_FROM_ACTION,
+		Owner:  &ownerAddr,
+		To:     utils.pubKey(toEdKey),
+		Files:  addDr.Data.Files,
+	})
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	sendDr = utils.createDelivery(t, delegateEdKey, DeliveryData{
+		Action: SEND_FROM_ACTION,
+		Owner:  &ownerAddr,
+		To:     utils.pubKey(toEdKey),
+		Files:  addDr.Data.Files[:1],
+	})
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr = utils.createDelivery(t, delegateEdKey, DeliveryData{
+		Action: SEND_FROM_ACTION,
+		Owner:  &ownerAddr,
+		To:     utils.pubKey(toEdKey),
+		Files:  addDr.Data.Files[1:],
+	})
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestSpbSendFromOnlyTheApprovedHashes(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	delegateEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	ownerAddr := ownerEdKey.PubKey().Address().String()
+
+	addDr := utils.createAddOrRemoveDelivery(t, ownerEdKey, ADD_ACTION,
+		[][]byte{[]byte("random1"), []byte("random2")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	a := Approval{Delegate: delegateEdKey.PubKey().Address().String(), Expiry: 3}
+	approveDr := utils.createDelivery(t, ownerEdKey, DeliveryData{
+		Action:   APPROVE_ACTION,
+		Files:    addDr.Data.Files[:1],
+		Approval: &a,
+	})
+	b, _ = json.Marshal(approveDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr := utils.createDelivery(t, delegateEdKey, DeliveryData{
+		Action: SEND_FROM_ACTION,
+		Owner:  &ownerAddr,
+		To:     utils.pubKey(toEdKey),
+		Files:  addDr.Data.Files[1:],
+	})
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	pba.Commit()
+	pba.Commit()
+
+	// the approval has expired
+	sendDr = utils.createDelivery(t, delegateEdKey, DeliveryData{
+		Action: SEND_FROM_ACTION,
+		Owner:  &ownerAddr,
+		To:     utils.pubKey(toEdKey),
+		Files:  addDr.Data.Files[:1],
+	})
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestSpbApproveRevoke(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	delegateEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	ownerAddr := ownerEdKey.PubKey().Address().String()
+	delegateAddr := delegateEdKey.PubKey().Address().String()
+
+	addDr := utils.createAddOrRemoveDelivery(t, ownerEdKey, ADD_ACTION, [][]byte{[]byte("random1"), []byte("random2")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	sendFrom := func(files []string) DeliveryRequest {
+		return utils.createDelivery(t, delegateEdKey, DeliveryData{
+			Action: SEND_FROM_ACTION,
+			Owner:  &ownerAddr,
+			To:     utils.pubKey(toEdKey),
+			Files:  files,
+		})
+	}
+
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"revoke without an approval", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:   APPROVE_REVOKE_ACTION,
+			Approval: &Approval{Delegate: delegateAddr},
+		}), CodeTypeUnauthorized},
+		// without Count and Expiry the approval has no limit
+		{"approve without limits", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:   APPROVE_ACTION,
+			Approval: &Approval{Delegate: delegateAddr},
+		}), CodeTypeOK},
+		{"send-from with the approval", sendFrom(addDr.Data.Files[:1]), CodeTypeOK},
+		{"revoke", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:   APPROVE_REVOKE_ACTION,
+			Approval: &Approval{Delegate: delegateAddr},
+		}), CodeTypeOK},
+		{"send-from after the revoke", sendFrom(addDr.Data.Files[1:]), CodeTypeUnauthorized},
+	})
+}
@@ -0,0 +1,124 @@
+package ctrls
+
+import (
+	"errors"
+	"strconv"
+
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+// isBatchable checks if the action needs only the signature of the From,
+// so it can be an operation of a batch.
+func (a ActionStruct) isBatchable() bool {
+	switch a {
+	case ADD_ACTION, REMOVE_ACTION, SEND_ACTION, HTLC_LOCK_ACTION, HTLC_CLAIM_ACTION, HTLC_RECLAIM_ACTION,
+		ESCROW_ACTION, MULTISIG_CREATE_ACTION, GUARDIANS_ACTION, RECOVERY_CANCEL_ACTION,
+		INHERITANCE_ACTION, CLAIM_INHERITANCE_ACTION, LEASE_ACTION, APPROVE_ACTION, APPROVE_REVOKE_ACTION, SEND_FROM_ACTION,
+		SUBKEY_ACTION, SUBKEY_REVOKE_ACTION, REGISTER_NAME_ACTION, TRANSFER_NAME_ACTION,
+		SPLIT_ACTION, MERGE_ACTION, ALERTS_ACTION:
+		return true
+	}
+	return false
+}
+
+// operations returns the operations of the batch as deliveries of the From of the batch.
+func (dr *DeliveryRequest) operations() []DeliveryRequest {
+	ops := []DeliveryRequest{}
+	for _, v := range dr.Data.Operations {
+		v.From = dr.Data.From
+		v.FromAccount = dr.Data.FromAccount
+		ops = append(ops, DeliveryRequest{Signature: dr.Signature, Data: v})
+	}
+	return ops
+}
+
+func (pba *PBApplication) batchActionValidation(dr DeliveryRequest) (uint32, error) {
+	if len(dr.Data.Operations) == 0 {
+		return CodeTypeUnauthorized, errors.New("There are no operations in the batch.")
+	}
+	if dr.Data.FromMultisig != nil {
+		return CodeTypeUnauthorized, errors.New("The batch can not be sent from a multisig.")
+	}
+	for _, v := range dr.Data.Operations {
+		if !v.Action.isBatchable() {
+			return CodeTypeUnauthorized, errors.New("The action " + string(v.Action) + " can not be in a batch.")
+		}
+	}
+
+	i, code, err := pba.sequenceValidation(dr.operations(), (*PBApplication).actionValidator, (*PBApplication).deliveryState)
+	if err != nil {
+		return code, errors.New("The operation " + strconv.Itoa(i+1) + " is not valid: " + err.Error())
+	}
+	return CodeTypeOK, nil
+}
+
+// sequenceValidation validates each delivery on the state that the previous deliveries left,
+// the journal reverts them after that. It returns the index of the delivery that failed.
+// The deliveries run on a copy of the application that writes through the journal
+// and has no pinner, so the application itself and its touched hashes do not change.
+func (pba *PBApplication) sequenceValidation(drs []DeliveryRequest,
+	validate func(*PBApplication, DeliveryRequest) (uint32, error),
+	apply func(*PBApplication, DeliveryRequest)) (int, uint32, error) {
+	jdb := &journalDB{DB: pba.state.db}
+	defer jdb.revert()
+	sub := &PBApplication{state: pba.state}
+	sub.state.db = jdb
+	for i, v := range drs {
+		code, err := validate(sub, v)
+		if err != nil {
+			return i, code, err
+		}
+		apply(sub, v)
+	}
+	return 0, CodeTypeOK, nil
+}
+
+// journalDB writes to the db and keeps the old values of the keys, so the writes can be reverted.
+type journalDB struct {
+	dbm.DB
+	entries []journalEntry
+}
+
+type journalEntry struct {
+	key     []byte
+	value   []byte
+	existed bool
+}
+
+func (jdb *journalDB) keep(key []byte) {
+	k := append([]byte{}, key...)
+	jdb.entries = append(jdb.entries, journalEntry{key: k, value: jdb.DB.Get(k), existed: jdb.DB.Has(k)})
+}
+
+func (jdb *journalDB) Set(key, value []byte) {
+	jdb.keep(key)
+	jdb.DB.Set(key, value)
+}
+
+func (jdb *journalDB) SetSync(key, value []byte) {
+	jdb.keep(key)
+	jdb.DB.SetSync(key, value)
+}
+
+func (jdb *journalDB) Delete(key []byte) {
+	jdb.keep(key)
+	jdb.DB.Delete(key)
+}
+
+func (jdb *journalDB) DeleteSync(key []byte) {
+	jdb.keep(key)
+	jdb.DB.DeleteSync(key)
+}
+
+// revert gives back the old values, from the last write to the first.
+func (jdb *journalDB) revert() {
+	for i := len(jdb.entries) - 1; i >= 0; i-- {
+		e := jdb.entries[i]
+		if e.existed {
+			jdb.DB.Set(e.key, e.value)
+		} else {
+			jdb.DB.Delete(e.key)
+		}
+	}
+	jdb.entries = nil
+}
@@ -0,0 +1,135 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbBatchAddSendAndRemoveSuccessfully(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	toB := toEdKey.PubKey().Bytes()
+
+	input := [][]byte{[]byte("random1"), []byte("random2"), []byte("random3")}
+	files := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, input).Data.Files
+	batchDr := utils.createDelivery(t, fromEdKey, DeliveryData{Action: BATCH_ACTION, Operations: []DeliveryData{
+		{Action: ADD_ACTION, Files: files},
+		{Action: SEND_ACTION, To: &toB, Files: files[:2]},
+		{Action: REMOVE_ACTION, Files: files[2:]},
+	}})
+	b, _ := json.Marshal(batchDr)
+	assert.Equal(t, CodeTypeOK, pba.CheckTx(b).Code)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, toEdKey, REMOVE_ACTION, input[:2])
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the third hash was removed, so it can be added again
+	addDr := utils.createAddOrRemoveDelivery(t, toEdKey, ADD_ACTION, input[2:])
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbBatchFailOnOneOperationAndAppliesNothing(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	toB := toEdKey.PubKey().Bytes()
+
+	input := [][]byte{[]byte("random1"), []byte("random2")}
+	files := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, input).Data.Files
+	// the second hash is sent before it is added
+	batchDr := utils.createDelivery(t, fromEdKey, DeliveryData{Action: BATCH_ACTION, Operations: []DeliveryData{
+		{Action: ADD_ACTION, Files: files[:1]},
+		{Action: SEND_ACTION, To: &toB, Files: files[1:]},
+		{Action: ADD_ACTION, Files: files[1:]},
+	}})
+	b, _ := json.Marshal(batchDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.CheckTx(b).Code)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	// the first add was reverted
+	addDr := utils.createAddOrRemoveDelivery(t, toEdKey, ADD_ACTION, input)
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbBatchFailOnActionThatNeedsOtherSignatures(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	toB := toEdKey.PubKey().Bytes()
+
+	batchDr := utils.createDelivery(t, fromEdKey, DeliveryData{Action: BATCH_ACTION, Operations: []DeliveryData{}})
+	b, _ := json.Marshal(batchDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	batchDr = utils.createDelivery(t, fromEdKey, DeliveryData{Action: BATCH_ACTION, Operations: []DeliveryData{
+		{Action: SWAP_ACTION, To: &toB},
+	}})
+	b, _ = json.Marshal(batchDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestOtopbBatchFailOnTwoAddsWithTheSameKey(t *testing.T) {
+	conf.Conf.Blockchain = conf.OtoOPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+
+	input := [][]byte{[]byte("random1"), []byte("random2")}
+	files := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, input).Data.Files
+	batchDr := utils.createDelivery(t, fromEdKey, DeliveryData{Action: BATCH_ACTION, Operations: []DeliveryData{
+		{Action: ADD_ACTION, Files: files[:1]},
+		{Action: ADD_ACTION, Files: files[1:]},
+	}})
+	b, _ := json.Marshal(batchDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestSpbBatchValidationChangesNothing(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	assert.Nil(t, pba.EnablePinning(""))
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	toB := toEdKey.PubKey().Bytes()
+
+	input := [][]byte{[]byte("random1"), []byte("random2")}
+	files := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, input).Data.Files
+	batchDr := utils.createDelivery(t, fromEdKey, DeliveryData{Action: BATCH_ACTION, Operations: []DeliveryData{
+		{Action: ADD_ACTION, Files: files},
+		{Action: SEND_ACTION, To: &toB, Files: files[:1]},
+	}})
+	b, _ := json.Marshal(batchDr)
+	assert.Equal(t, CodeTypeOK, pba.CheckTx(b).Code)
+	assert.Empty(t, pba.touched)
+	assert.False(t, pba.state.db.Has(prefixFileKey(files[0])))
+
+	// the rejected batch does not give its hashes to the pinner
+	failDr := utils.createDelivery(t, fromEdKey, DeliveryData{Action: BATCH_ACTION, Operations: []DeliveryData{
+		{Action: ADD_ACTION, Files: files[:1]},
+		{Action: SEND_ACTION, To: &toB, Files: files[1:]},
+	}})
+	fb, _ := json.Marshal(failDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(fb).Code)
+	assert.Empty(t, pba.touched)
+
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	for _, v := range files {
+		assert.Contains(t, pba.touched, v)
+	}
+}
@@ -0,0 +1,68 @@
+package ctrls
+
+import (
+	"errors"
+)
+
+// bannedFilesValidation refuses the hashes in the blocklist.
+func (pba *PBApplication) bannedFilesValidation(files []string) (uint32, error) {
+	for _, v := range files {
+		if pba.state.db.Has(prefixBannedKey(v)) {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is banned.")
+		}
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) banActionValidation(dr DeliveryRequest) (uint32, error) {
+	code, err := pba.adminValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	if len(dr.Data.Files) == 0 {
+		return CodeTypeUnauthorized, errors.New("There are no hashes to ban.")
+	}
+	for _, v := range dr.Data.Files {
+		banned := pba.state.db.Has(prefixBannedKey(v))
+		if dr.Data.Action == BAN_ACTION && banned {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is already banned.")
+		}
+		if dr.Data.Action == UNBAN_ACTION && !banned {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is not banned.")
+		}
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) banActionState(dr DeliveryRequest) {
+	for _, v := range dr.Data.Files {
+		pba.state.db.Set(prefixBannedKey(v), []byte(*dr.Data.Reason))
+		if dr.Data.ForceRemove {
+			pba.forceRemove(v)
+		}
+	}
+	pba.audit(dr, "")
+}
+
+// forceRemove removes the file from its owner, even when it is locked, leased, vesting or in escrow.
+func (pba *PBApplication) forceRemove(file string) {
+	owner := pba.state.db.Get(prefixFileKey(file))
+	if len(owner) == 0 {
+		return
+	}
+	pba.removeFilesFromUserKey(string(owner), []string{file})
+	pba.state.db.Delete(prefixFileKey(file))
+	pba.state.db.Delete(prefixLockKey(file))
+	pba.state.db.Delete(prefixLeaseKey(file))
+	pba.state.db.Delete(prefixVestKey(file))
+	pba.state.db.Delete(prefixEscrowKey(file))
+	pba.state.db.Delete(prefixIssuerKey(file))
+	pba.state.db.Delete(prefixAttestKey(file))
+}
+
+func (pba *PBApplication) unbanActionState(dr DeliveryRequest) {
+	for _, v := range dr.Data.Files {
+		pba.state.db.Delete(prefixBannedKey(v))
+	}
+	pba.audit(dr, "")
+}
@@ -24,49 +24,114 @@ func (pba *PBApplication) addActionValidation(dr DeliveryRequest) (uint32, error
 		}
 
 	}
+	fromAddr, _ := dr.FromPubKeyAddress()
+	code, err := pba.registeredValidation(fromAddr)
+	if err != nil {
+		return code, err
+	}
 	// check if the files already exists
 	for _, v := range dr.Data.Files {
 		has := pba.state.db.Has(prefixFileKey(v))
 		if has {
 			return CodeTypeUnauthorized, errors.New("The hash " + v + " already exists.")
 		}
+		// the notes of a split or a merge can not come back
+		if pba.state.db.Has(prefixBurnedKey(v)) {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " has been burned.")
+		}
 	}
-	return CodeTypeOK, nil
+	code, err = pba.bannedFilesValidation(dr.Data.Files)
+	if err != nil {
+		return code, err
+	}
+	return pba.schemaValidation(dr)
 }
 
 func (pba *PBApplication) sendActionValidation(dr DeliveryRequest) (uint32, error) {
-	if dr.Data.To == nil {
-		return CodeTypeUnauthorized, errors.New("The public key of the receiver does not exists.")
-	}
-
-	to, err := crypto.PubKeyFromBytes(*dr.Data.To)
+	code, err := pba.receiverValidation(dr)
 	if err != nil {
-		return CodeTypeEncodingError, errors.New("The public key of the receiver is not correct.")
+		return code, err
 	}
 
-	from, err := crypto.PubKeyFromBytes(dr.Data.From)
+	_, err = crypto.PubKeyFromBytes(dr.Data.From)
 	if err != nil {
 		return CodeTypeEncodingError, errors.New("The public key of the sender is not correct.")
 	}
 
-	if from == to {
-		return CodeTypeUnauthorized, errors.New("The public key of the receiver is the same as the senders.")
-	}
 	toAddr, _ := dr.ToPubKeyAddress()
-	if conf.Conf.Blockchain == conf.OtoOPB {
-		if pba.state.db.Has(prefixUserKey(toAddr)) {
-			return CodeTypeUnauthorized, errors.New("The public key of the receiver exists in the DB.")
+	fromAddr, _ := dr.FromPubKeyAddress()
+	return pba.transferValidation(fromAddr, toAddr, dr.Data.Files)
+}
+
+// receiverValidation checks the public key or the multisig address of the receiver.
+func (pba *PBApplication) receiverValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.ToMultisig != nil {
+		if !pba.state.db.Has(prefixMultisigKey(*dr.Data.ToMultisig)) {
+			return CodeTypeUnauthorized, errors.New("The multisig address of the receiver does not exists.")
+		}
+	} else {
+		if dr.Data.To == nil {
+			return CodeTypeUnauthorized, errors.New("The public key of the receiver does not exists.")
+		}
+
+		if dr.ToIsAddress() {
+			// the fresh key of the receiver needs to be proven by its public key
+			if conf.Conf.Blockchain == conf.OtoOPB {
+				return CodeTypeUnauthorized,
+					errors.New("For one to one blockchain, the receiver needs to be a public key.")
+			}
+			return CodeTypeOK, nil
+		}
+		_, err := crypto.PubKeyFromBytes(*dr.Data.To)
+		if err != nil {
+			return CodeTypeEncodingError, errors.New("The public key of the receiver is not correct.")
 		}
 	}
+	return CodeTypeOK, nil
+}
 
-	fromAddr, _ := dr.FromPubKeyAddress()
-	for _, v := range dr.Data.Files {
+// transferValidation checks that the files of the fromAddr can move to the toAddr.
+func (pba *PBApplication) transferValidation(fromAddr, toAddr string, files []string) (uint32, error) {
+	code, err := pba.addressesValidation(fromAddr, toAddr)
+	if err != nil {
+		return code, err
+	}
+
+	for _, v := range files {
 		user := pba.state.db.Get(prefixFileKey(v))
 		if string(user) != fromAddr {
 			return CodeTypeUnauthorized, errors.New("You dont own The hash " + v + ".")
 		}
 	}
 
+	code, err = pba.bannedFilesValidation(files)
+	if err != nil {
+		return code, err
+	}
+	return pba.movableFilesValidation(files)
+}
+
+// addressesValidation checks that the fromAddr can give files to the toAddr.
+func (pba *PBApplication) addressesValidation(fromAddr, toAddr string) (uint32, error) {
+	if fromAddr == toAddr {
+		return CodeTypeUnauthorized, errors.New("The public key of the receiver is the same as the senders.")
+	}
+	code, err := pba.registeredValidation(fromAddr)
+	if err != nil {
+		return code, err
+	}
+	code, err = pba.registeredValidation(toAddr)
+	if err != nil {
+		return code, err
+	}
+	if pba.state.db.Has(prefixRetiredKey(toAddr)) {
+		return CodeTypeUnauthorized, errors.New("The address of the receiver is retired.")
+	}
+	if conf.Conf.Blockchain == conf.OtoOPB {
+		if pba.state.db.Has(prefixUserKey(toAddr)) {
+			return CodeTypeUnauthorized, errors.New("The public key of the receiver exists in the DB.")
+		}
+	}
 	return CodeTypeOK, nil
 }
 
@@ -81,10 +146,47 @@ func (pba *PBApplication) removeActionValidation(dr DeliveryRequest) (uint32, er
 			return CodeTypeUnauthorized, errors.New("The hash " + v + " is not owned by you.")
 		}
 	}
+	return pba.movableFilesValidation(dr.Data.Files)
+}
+
+// movableFilesValidation refuses the files that their owner
+// is not allowed to send or remove at the moment.
+func (pba *PBApplication) movableFilesValidation(files []string) (uint32, error) {
+	code, err := pba.frozenFilesValidation(files)
+	if err != nil {
+		return code, err
+	}
+	for _, v := range files {
+		if pba.state.db.Has(prefixLockKey(v)) {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is locked.")
+		}
+		if pba.state.db.Has(prefixLeaseKey(v)) {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is leased.")
+		}
+		if vl := pba.getVesting(v); vl != nil && vl.Locked(pba.currentHeight(), pba.state.Time) {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is vesting.")
+		}
+	}
+	return CodeTypeOK, nil
+}
+
+// ipfsFilesValidation checks that the files exist in the IPFS.
+func ipfsFilesValidation(files []string) (uint32, error) {
+	sh := shell.NewShell(conf.Conf.IpfsConnection)
+	for _, v := range files {
+		_, err := sh.BlockGet(v)
+		if err != nil {
+			return CodeTypeEncodingError,
+				errors.New("The file " + v + " does not exists.")
+		}
+	}
 	return CodeTypeOK, nil
 }
 
 func (pba *PBApplication) deliverTxValidator(dr DeliveryRequest) (uint32, error) {
+	if pba.state.db.Has(pausedKey) && !dr.Data.Action.isAdmin() {
+		return CodeTypePaused, errors.New("The chain is paused.")
+	}
 	pubk, err := crypto.PubKeyFromBytes(dr.Data.From)
 	if err != nil {
 		return CodeTypeEncodingError, errors.New("Public key is not correct.")
@@ -98,14 +200,35 @@ func (pba *PBApplication) deliverTxValidator(dr DeliveryRequest) (uint32, error)
 	if !isVerified {
 		return CodeTypeUnauthorized, errors.New("The signature does not validate the data.")
 	}
+	return pba.actionValidator(dr)
+}
 
-	// check if the hashes exist in the IPFS
-	sh := shell.NewShell(conf.Conf.IpfsConnection)
-	for _, v := range dr.Data.Files {
-		_, err := sh.BlockGet(v)
+// actionValidator validates the delivery after its signature,
+// the operations of a batch are validated with it one by one.
+func (pba *PBApplication) actionValidator(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.FromMultisig != nil {
+		code, err := pba.multisigValidation(dr)
 		if err != nil {
-			return CodeTypeEncodingError,
-				errors.New("The file " + v + " does not exists.")
+			return code, err
+		}
+	}
+	if dr.Data.FromAccount != nil {
+		code, err := pba.subKeyValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	}
+	fromAddr, _ := dr.FromPubKeyAddress()
+	if pba.state.db.Has(prefixRetiredKey(fromAddr)) {
+		return CodeTypeUnauthorized, errors.New("The address " + fromAddr + " is retired.")
+	}
+
+	// check if the hashes exist in the IPFS, the banned hashes and the old schemas could be removed from it
+	if dr.Data.Action != BAN_ACTION && dr.Data.Action != UNBAN_ACTION && dr.Data.Action != SCHEMA_UNREGISTER_ACTION {
+		files := append(append(dr.Data.Files, dr.Data.ToFiles...), dr.Data.NewFiles...)
+		code, err := ipfsFilesValidation(files)
+		if err != nil {
+			return code, err
 		}
 	}
 
@@ -115,16 +238,184 @@ func (pba *PBApplication) deliverTxValidator(dr DeliveryRequest) (uint32, error)
 		if err != nil {
 			return code, err
 		}
+		code, err = pba.issuerValidation(dr)
+		if err != nil {
+			return code, err
+		}
 	case REMOVE_ACTION:
 		code, err := pba.removeActionValidation(dr)
 		if err != nil {
 			return code, err
 		}
 	case SEND_ACTION:
+		if len(dr.Data.Recipients) > 0 {
+			return pba.recipientsValidation(dr)
+		}
 		code, err := pba.sendActionValidation(dr)
 		if err != nil {
 			return code, err
 		}
+		if dr.Data.Vesting != nil {
+			code, err := pba.vestingValidation(dr)
+			if err != nil {
+				return code, err
+			}
+		}
+	case SWAP_ACTION:
+		code, err := pba.swapActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case HTLC_LOCK_ACTION:
+		code, err := pba.htlcLockActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case HTLC_CLAIM_ACTION:
+		code, err := pba.htlcClaimActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case HTLC_RECLAIM_ACTION:
+		code, err := pba.htlcReclaimActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case ESCROW_ACTION:
+		code, err := pba.escrowActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case ESCROW_RELEASE_ACTION, ESCROW_REFUND_ACTION:
+		code, err := pba.escrowSettleValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case MULTISIG_CREATE_ACTION:
+		code, err := pba.multisigCreateActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case ROTATE_ACTION:
+		code, err := pba.rotateActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case GUARDIANS_ACTION:
+		code, err := pba.guardiansActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case RECOVERY_ACTION:
+		code, err := pba.recoveryActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case RECOVERY_CANCEL_ACTION:
+		code, err := pba.recoveryCancelActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case INHERITANCE_ACTION:
+		code, err := pba.inheritanceActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case CLAIM_INHERITANCE_ACTION:
+		code, err := pba.claimInheritanceActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case LEASE_ACTION:
+		code, err := pba.leaseActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case APPROVE_ACTION:
+		code, err := pba.approveActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case APPROVE_REVOKE_ACTION:
+		code, err := pba.approveRevokeActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case SEND_FROM_ACTION:
+		code, err := pba.sendFromActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case SUBKEY_ACTION:
+		code, err := pba.subKeyActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case SUBKEY_REVOKE_ACTION:
+		code, err := pba.subKeyRevokeActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case FREEZE_ACTION, UNFREEZE_ACTION:
+		code, err := pba.freezeActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case FORCED_TRANSFER_ACTION:
+		code, err := pba.forcedTransferActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case PAUSE_ACTION, UNPAUSE_ACTION:
+		code, err := pba.pauseActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case BAN_ACTION, UNBAN_ACTION:
+		code, err := pba.banActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case REGISTER_ACTION, UNREGISTER_ACTION:
+		code, err := pba.registerActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case REGISTER_NAME_ACTION:
+		code, err := pba.registerNameActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case TRANSFER_NAME_ACTION:
+		code, err := pba.transferNameActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case BATCH_ACTION:
+		code, err := pba.batchActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case SPLIT_ACTION, MERGE_ACTION:
+		code, err := pba.noteActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case ISSUERS_ACTION:
+		code, err := pba.issuersActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case SCHEMA_REGISTER_ACTION, SCHEMA_UNREGISTER_ACTION:
+		code, err := pba.schemaActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
+	case ALERTS_ACTION:
+		code, err := pba.alertsActionValidation(dr)
+		if err != nil {
+			return code, err
+		}
 	}
 
 	return CodeTypeOK, nil
@@ -135,7 +426,9 @@ func (pba *PBApplication) addActionState(dr DeliveryRequest) {
 	pba.addFilesToUserKey(fromAddr, dr.Data.Files)
 	for _, v := range dr.Data.Files {
 		pba.state.db.Set(prefixFileKey(v), []byte(fromAddr))
+		pba.state.db.Set(prefixIssuerKey(v), []byte(fromAddr))
 	}
+	pba.attestSchema(dr)
 }
 
 func (pba *PBApplication) addFilesToUserKey(fromAddr string, addFiles []string) {
@@ -173,20 +466,28 @@ func (pba *PBApplication) removeActionState(dr DeliveryRequest) {
 	pba.removeFilesFromUserKey(fromAddr, dr.Data.Files)
 	for _, v := range dr.Data.Files {
 		pba.state.db.Delete(prefixFileKey(v))
+		pba.state.db.Delete(prefixVestKey(v))
+		pba.state.db.Delete(prefixIssuerKey(v))
+		pba.state.db.Delete(prefixAttestKey(v))
 	}
 }
 
 func (pba *PBApplication) sendActionState(dr DeliveryRequest) {
 	toAddr, _ := dr.ToPubKeyAddress()
 	fromAddr, _ := dr.FromPubKeyAddress()
-	pba.removeFilesFromUserKey(fromAddr, dr.Data.Files)
-	pba.addFilesToUserKey(toAddr, dr.Data.Files)
+	pba.transferFiles(fromAddr, toAddr, dr.Data.Files)
+	pba.vestingState(dr)
+}
+
+func (pba *PBApplication) transferFiles(fromAddr, toAddr string, files []string) {
+	pba.removeFilesFromUserKey(fromAddr, files)
+	pba.addFilesToUserKey(toAddr, files)
 
 	if conf.Conf.Blockchain == conf.OtoOPB {
 		pba.state.db.Set(prefixUserKey(toAddr), nil)
 		pba.state.db.Delete(prefixUserKey(fromAddr))
 	}
-	for _, v := range dr.Data.Files {
+	for _, v := range files {
 		pba.state.db.Set(prefixFileKey(v), []byte(toAddr))
 	}
 }
@@ -201,15 +502,94 @@ func (pba *PBApplication) DeliverTx(tx []byte) types.ResponseDeliverTx {
 	if err != nil {
 		return types.ResponseDeliverTx{Code: code, Log: err.Error()}
 	}
+	pba.deliveryState(dr)
+
+	return types.ResponseDeliverTx{Code: code}
+}
 
+// deliveryState applies the validated delivery on the state.
+func (pba *PBApplication) deliveryState(dr DeliveryRequest) {
 	switch action := dr.Data.Action; action {
 	case ADD_ACTION:
 		pba.addActionState(dr)
 	case REMOVE_ACTION:
 		pba.removeActionState(dr)
 	case SEND_ACTION:
+		if len(dr.Data.Recipients) > 0 {
+			pba.recipientsState(dr)
+			break
+		}
 		pba.sendActionState(dr)
+	case SWAP_ACTION:
+		pba.swapActionState(dr)
+	case HTLC_LOCK_ACTION:
+		pba.htlcLockActionState(dr)
+	case HTLC_CLAIM_ACTION:
+		pba.htlcClaimActionState(dr)
+	case HTLC_RECLAIM_ACTION:
+		pba.htlcReclaimActionState(dr)
+	case ESCROW_ACTION:
+		pba.escrowActionState(dr)
+	case ESCROW_RELEASE_ACTION, ESCROW_REFUND_ACTION:
+		pba.escrowSettleState(dr)
+	case MULTISIG_CREATE_ACTION:
+		pba.multisigCreateActionState(dr)
+	case ROTATE_ACTION:
+		pba.rotateActionState(dr)
+	case GUARDIANS_ACTION:
+		pba.guardiansActionState(dr)
+	case RECOVERY_ACTION:
+		pba.recoveryActionState(dr)
+	case RECOVERY_CANCEL_ACTION:
+		pba.recoveryCancelActionState(dr)
+	case INHERITANCE_ACTION:
+		pba.inheritanceActionState(dr)
+	case CLAIM_INHERITANCE_ACTION:
+		pba.claimInheritanceActionState(dr)
+	case LEASE_ACTION:
+		pba.leaseActionState(dr)
+	case APPROVE_ACTION:
+		pba.approveActionState(dr)
+	case APPROVE_REVOKE_ACTION:
+		pba.approveRevokeActionState(dr)
+	case SEND_FROM_ACTION:
+		pba.sendFromActionState(dr)
+	case SUBKEY_ACTION:
+		pba.subKeyActionState(dr)
+	case SUBKEY_REVOKE_ACTION:
+		pba.subKeyRevokeActionState(dr)
+	case FREEZE_ACTION:
+		pba.freezeActionState(dr)
+	case UNFREEZE_ACTION:
+		pba.unfreezeActionState(dr)
+	case FORCED_TRANSFER_ACTION:
+		pba.forcedTransferActionState(dr)
+	case PAUSE_ACTION, UNPAUSE_ACTION:
+		pba.pauseActionState(dr)
+	case BAN_ACTION:
+		pba.banActionState(dr)
+	case UNBAN_ACTION:
+		pba.unbanActionState(dr)
+	case REGISTER_ACTION, UNREGISTER_ACTION:
+		pba.registerActionState(dr)
+	case REGISTER_NAME_ACTION:
+		pba.setName(*dr.Data.Name, dr.Data.From)
+	case TRANSFER_NAME_ACTION:
+		pba.setName(*dr.Data.Name, *dr.Data.To)
+	case BATCH_ACTION:
+		for _, op := range dr.operations() {
+			pba.deliveryState(op)
+		}
+	case SPLIT_ACTION, MERGE_ACTION:
+		pba.noteActionState(dr)
+	case ISSUERS_ACTION:
+		pba.issuersActionState(dr)
+	case SCHEMA_REGISTER_ACTION, SCHEMA_UNREGISTER_ACTION:
+		pba.schemaActionState(dr)
+	case ALERTS_ACTION:
+		pba.alertsActionState(dr)
 	}
-
-	return types.ResponseDeliverTx{Code: code}
+	pba.recordActivity(dr)
+	pba.recordSubKeyAdds(dr)
+	pba.touchFiles(dr)
 }
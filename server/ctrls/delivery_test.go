@@ -57,6 +57,79 @@ func (f forTestUtils) createSendDelivery(t *testing.T, from crypto.PrivKeyEd2551
 	return dr
 }
 
+// createDelivery signs the data with the from, the others sign it too
+// like the members of a multisig, the guardians of a recovery or the parties of an escrow.
+func (f forTestUtils) createDelivery(t *testing.T, from crypto.PrivKeyEd25519, dd DeliveryData,
+	others ...crypto.PrivKeyEd25519) DeliveryRequest {
+	dd.From = from.PubKey().Bytes()
+	b, _ := json.Marshal(dd)
+	dr := DeliveryRequest{}
+	dr.Signature = from.Sign(b).Bytes()
+	for _, v := range others {
+		dr.Signatures = append(dr.Signatures, KeySignature{
+			PubKey:    v.PubKey().Bytes(),
+			Signature: v.Sign(b).Bytes(),
+		})
+	}
+	dr.Data = dd
+	return dr
+}
+
+// coSign adds the signature of the counterparty of a swap or of the new key of a rotation.
+func (f forTestUtils) coSign(dr DeliveryRequest, to crypto.PrivKeyEd25519) DeliveryRequest {
+	b, _ := json.Marshal(dr.Data)
+	dr.CoSignature = to.Sign(b).Bytes()
+	return dr
+}
+
+// asSubKey makes the data of a delivery to be signed by a sub-key of the account.
+func (f forTestUtils) asSubKey(dd DeliveryData, account string) DeliveryData {
+	dd.FromAccount = &account
+	return dd
+}
+
+func (f forTestUtils) pubKey(key crypto.PrivKeyEd25519) *[]byte {
+	b := key.PubKey().Bytes()
+	return &b
+}
+
+func (f forTestUtils) address(key crypto.PrivKeyEd25519) string {
+	return key.PubKey().Address().String()
+}
+
+func (f forTestUtils) addresses(keys ...crypto.PrivKeyEd25519) []string {
+	addrs := []string{}
+	for _, v := range keys {
+		addrs = append(addrs, f.address(v))
+	}
+	return addrs
+}
+
+func (f forTestUtils) str(s string) *string {
+	return &s
+}
+
+// deliver delivers the request and checks the code that it returns.
+func (f forTestUtils) deliver(t *testing.T, pba *PBApplication, dr DeliveryRequest, code uint32) {
+	b, _ := json.Marshal(dr)
+	assert.Equal(t, code, pba.DeliverTx(b).Code)
+}
+
+// deliveryCase is a step of a table test, the delivery and the code that it returns.
+type deliveryCase struct {
+	name string
+	dr   DeliveryRequest
+	code uint32
+}
+
+// deliverCases delivers the cases in order, so each case sees the state of the previous cases.
+func (f forTestUtils) deliverCases(t *testing.T, pba *PBApplication, cases []deliveryCase) {
+	for _, v := range cases {
+		b, _ := json.Marshal(v.dr)
+		assert.Equal(t, v.code, pba.DeliverTx(b).Code, v.name)
+	}
+}
+
 func TestDeliverySuccesfulAdd(t *testing.T) {
 	pba := NewPBApplication()
 	edKey := crypto.GenPrivKeyEd25519()
@@ -294,3 +367,49 @@ func TestOtopbDeliverySendSuccessWhenReusesTheSameKeyAfterSendForDifferentFile(t
 	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
 
 }
+
+func TestSpbDeliverySendToAddressSuccessfully(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	input := [][]byte{[]byte("random1")}
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr := utils.createSendDelivery(t, fromEdKey, nil, SEND_ACTION, addDr.Data.Files)
+	toB := []byte(toEdKey.PubKey().Address())
+	sendDr.Data.To = &toB
+	b, _ = json.Marshal(sendDr.Data)
+	sendDr.Signature = fromEdKey.Sign(b).Bytes()
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDrForTo := utils.createAddOrRemoveDelivery(t, toEdKey, REMOVE_ACTION, input)
+	b, _ = json.Marshal(remDrForTo)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestOtopbDeliverySendFailOnAddress(t *testing.T) {
+	conf.Conf.Blockchain = conf.OtoOPB
+	pba := NewPBApplication()
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	input := [][]byte{[]byte("random1")}
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr := utils.createSendDelivery(t, fromEdKey, nil, SEND_ACTION, addDr.Data.Files)
+	toB := []byte(toEdKey.PubKey().Address())
+	sendDr.Data.To = &toB
+	b, _ = json.Marshal(sendDr.Data)
+	sendDr.Signature = fromEdKey.Sign(b).Bytes()
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
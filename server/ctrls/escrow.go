@@ -0,0 +1,158 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/tendermint/go-crypto"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+func (pba *PBApplication) getEscrow(file string) *Escrow {
+	b := pba.state.db.Get(prefixEscrowKey(file))
+	if len(b) == 0 {
+		return nil
+	}
+	e := Escrow{}
+	json.Unmarshal(b, &e)
+	return &e
+}
+
+// escrowedFiles returns the files in escrow that the address is one of the parties.
+func (pba *PBApplication) escrowedFiles(addr string) []string {
+	files := []string{}
+	itr := dbm.IteratePrefix(pba.state.db, escrowKey)
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		e := Escrow{}
+		json.Unmarshal(itr.Value(), &e)
+		if e.Seller == addr || e.Buyer == addr || e.Arbiter == addr {
+			files = append(files, string(itr.Key()[len(escrowKey):]))
+		}
+	}
+	return files
+}
+
+// moveEscrows gives the place of the old address in the escrows to the new one,
+// so it signs the settlements and receives the files.
+func (pba *PBApplication) moveEscrows(oldAddr, newAddr string) {
+	keys := [][]byte{}
+	escrows := []Escrow{}
+	itr := dbm.IteratePrefix(pba.state.db, escrowKey)
+	for ; itr.Valid(); itr.Next() {
+		e := Escrow{}
+		json.Unmarshal(itr.Value(), &e)
+		moved := false
+		for _, party := range []*string{&e.Seller, &e.Buyer, &e.Arbiter} {
+			if *party == oldAddr {
+				*party = newAddr
+				moved = true
+			}
+		}
+		if moved {
+			keys = append(keys, itr.Key())
+			escrows = append(escrows, e)
+		}
+	}
+	itr.Close()
+
+	for i, k := range keys {
+		b, _ := json.Marshal(escrows[i])
+		pba.state.db.Set(k, b)
+	}
+}
+
+func (pba *PBApplication) escrowActionValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.Arbiter == nil {
+		return CodeTypeUnauthorized, errors.New("The public key of the arbiter does not exists.")
+	}
+	arbiter, err := crypto.PubKeyFromBytes(*dr.Data.Arbiter)
+	if err != nil {
+		return CodeTypeEncodingError, errors.New("The public key of the arbiter is not correct.")
+	}
+	arbiterAddr := arbiter.Address().String()
+	fromAddr, _ := dr.FromPubKeyAddress()
+	toAddr, _ := dr.ToPubKeyAddress()
+	if arbiterAddr == fromAddr || arbiterAddr == toAddr {
+		return CodeTypeUnauthorized, errors.New("The arbiter can not be the seller or the buyer.")
+	}
+	// the seller gives the files to the chain in the same way that sends them to the buyer
+	return pba.sendActionValidation(dr)
+}
+
+// escrowSettleValidation checks that two of the three parties
+// have signed the release or the refund of the files.
+func (pba *PBApplication) escrowSettleValidation(dr DeliveryRequest) (uint32, error) {
+	if len(dr.Data.Files) == 0 {
+		return CodeTypeUnauthorized, errors.New("There are no files in the delivery.")
+	}
+	signers := dr.SignerAddresses()
+	for _, v := range dr.Data.Files {
+		e := pba.getEscrow(v)
+		if e == nil {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is not in escrow.")
+		}
+		approvals := 0
+		for _, party := range []string{e.Seller, e.Buyer, e.Arbiter} {
+			if signers[party] {
+				approvals++
+			}
+		}
+		if approvals < 2 {
+			return CodeTypeUnauthorized,
+				errors.New("The hash " + v + " needs the signatures of two of the parties of the escrow.")
+		}
+		receiver := e.Buyer
+		if dr.Data.Action == ESCROW_REFUND_ACTION {
+			receiver = e.Seller
+		}
+		// the receiver may have been retired or unregistered after the escrow
+		if pba.state.db.Has(prefixRetiredKey(receiver)) {
+			return CodeTypeUnauthorized, errors.New("The address of the receiver is retired.")
+		}
+		code, err := pba.registeredValidation(receiver)
+		if err != nil {
+			return code, err
+		}
+		if conf.Conf.Blockchain == conf.OtoOPB && pba.state.db.Has(prefixUserKey(receiver)) {
+			return CodeTypeUnauthorized, errors.New("The public key of the receiver exists in the DB.")
+		}
+	}
+	// the hashes that were banned or frozen after the escrow stay in the escrow
+	code, err := pba.bannedFilesValidation(dr.Data.Files)
+	if err != nil {
+		return code, err
+	}
+	return pba.movableFilesValidation(dr.Data.Files)
+}
+
+func (pba *PBApplication) escrowActionState(dr DeliveryRequest) {
+	arbiter, _ := crypto.PubKeyFromBytes(*dr.Data.Arbiter)
+	fromAddr, _ := dr.FromPubKeyAddress()
+	toAddr, _ := dr.ToPubKeyAddress()
+	e := Escrow{
+		Seller:  fromAddr,
+		Buyer:   toAddr,
+		Arbiter: arbiter.Address().String(),
+	}
+	b, _ := json.Marshal(e)
+
+	pba.removeFilesFromUserKey(fromAddr, dr.Data.Files)
+	for _, v := range dr.Data.Files {
+		pba.state.db.Set(prefixFileKey(v), []byte(escrowHolder))
+		pba.state.db.Set(prefixEscrowKey(v), b)
+	}
+}
+
+func (pba *PBApplication) escrowSettleState(dr DeliveryRequest) {
+	for _, v := range dr.Data.Files {
+		e := pba.getEscrow(v)
+		receiver := e.Buyer
+		if dr.Data.Action == ESCROW_REFUND_ACTION {
+			receiver = e.Seller
+		}
+		pba.state.db.Delete(prefixEscrowKey(v))
+		pba.transferFiles(escrowHolder, receiver, []string{v})
+	}
+}
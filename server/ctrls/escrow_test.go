@@ -0,0 +1,160 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbEscrowReleaseSuccessfully(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	sellerEdKey := crypto.GenPrivKeyEd25519()
+	buyerEdKey := crypto.GenPrivKeyEd25519()
+	arbiterEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	addDr := utils.createAddOrRemoveDelivery(t, sellerEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	escrowDr := utils.createDelivery(t, sellerEdKey, DeliveryData{
+		Action:  ESCROW_ACTION,
+		To:      utils.pubKey(buyerEdKey),
+		Arbiter: utils.pubKey(arbiterEdKey),
+		Files:   addDr.Data.Files,
+	})
+	b, _ = json.Marshal(escrowDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the seller does not own the file anymore
+	remDr := utils.createAddOrRemoveDelivery(t, sellerEdKey, REMOVE_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	releaseDr := utils.createDelivery(t, buyerEdKey, DeliveryData{
+		Action: ESCROW_RELEASE_ACTION,
+		Files:  addDr.Data.Files,
+	})
+	b, _ = json.Marshal(releaseDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	releaseDr = utils.createDelivery(t, buyerEdKey, DeliveryData{
+		Action: ESCROW_RELEASE_ACTION,
+		Files:  addDr.Data.Files,
+	}, arbiterEdKey)
+	b, _ = json.Marshal(releaseDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr = utils.createAddOrRemoveDelivery(t, buyerEdKey, REMOVE_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbEscrowRefundSuccessfully(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	sellerEdKey := crypto.GenPrivKeyEd25519()
+	buyerEdKey := crypto.GenPrivKeyEd25519()
+	arbiterEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	addDr := utils.createAddOrRemoveDelivery(t, sellerEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	escrowDr := utils.createDelivery(t, sellerEdKey, DeliveryData{
+		Action:  ESCROW_ACTION,
+		To:      utils.pubKey(buyerEdKey),
+		Arbiter: utils.pubKey(arbiterEdKey),
+		Files:   addDr.Data.Files,
+	})
+	b, _ = json.Marshal(escrowDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	refundDr := utils.createDelivery(t, arbiterEdKey, DeliveryData{
+		Action: ESCROW_REFUND_ACTION,
+		Files:  addDr.Data.Files,
+	}, sellerEdKey)
+	b, _ = json.Marshal(refundDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, sellerEdKey, REMOVE_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbEscrowFollowsTheRotationOfTheBuyer(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	sellerEdKey := crypto.GenPrivKeyEd25519()
+	buyerEdKey := crypto.GenPrivKeyEd25519()
+	arbiterEdKey := crypto.GenPrivKeyEd25519()
+	newEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	addDr := utils.createAddOrRemoveDelivery(t, sellerEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files", addDr, CodeTypeOK},
+		{"put the files in escrow", utils.createDelivery(t, sellerEdKey, DeliveryData{
+			Action:  ESCROW_ACTION,
+			To:      utils.pubKey(buyerEdKey),
+			Arbiter: utils.pubKey(arbiterEdKey),
+			Files:   addDr.Data.Files,
+		}), CodeTypeOK},
+		{"the buyer retires its key", utils.coSign(utils.createDelivery(t, buyerEdKey, DeliveryData{
+			Action: ROTATE_ACTION,
+			To:     utils.pubKey(newEdKey),
+		}), newEdKey), CodeTypeOK},
+		{"the retired key can not sign the release", utils.createDelivery(t, arbiterEdKey, DeliveryData{
+			Action: ESCROW_RELEASE_ACTION,
+			Files:  addDr.Data.Files,
+		}, buyerEdKey), CodeTypeUnauthorized},
+		{"the new key signs the release", utils.createDelivery(t, newEdKey, DeliveryData{
+			Action: ESCROW_RELEASE_ACTION,
+			Files:  addDr.Data.Files,
+		}, arbiterEdKey), CodeTypeOK},
+	})
+	assert.Equal(t, addDr.Data.Files, pba.getUserFiles(utils.address(newEdKey)))
+}
+
+func TestSpbQueryShowsFilesInEscrow(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	conf.Conf.WaitingSecondsQuery = 5
+	pba := NewPBApplication()
+	sellerEdKey := crypto.GenPrivKeyEd25519()
+	buyerEdKey := crypto.GenPrivKeyEd25519()
+	arbiterEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	addDr := utils.createAddOrRemoveDelivery(t, sellerEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	escrowDr := utils.createDelivery(t, sellerEdKey, DeliveryData{
+		Action:  ESCROW_ACTION,
+		To:      utils.pubKey(buyerEdKey),
+		Arbiter: utils.pubKey(arbiterEdKey),
+		Files:   addDr.Data.Files,
+	})
+	b, _ = json.Marshal(escrowDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	q := utils.querySpb(t, buyerEdKey, nil, nil)
+	b, _ = json.Marshal(q)
+	req := types.RequestQuery{}
+	req.Data = b
+
+	qr := QueryResponse{}
+	qr.Files = []string{}
+	qr.InEscrow = addDr.Data.Files
+	qr.Issuers = []FileIssuer{{File: addDr.Data.Files[0], Issuer: sellerEdKey.PubKey().Address().String()}}
+	b, _ = json.Marshal(qr)
+	res := types.ResponseQuery{Code: CodeTypeOK}
+	res.Value = b
+	assert.Equal(t, res, pba.Query(req))
+}
@@ -0,0 +1,180 @@
+package ctrls
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+func (pba *PBApplication) getHashLock(file string) *HashLock {
+	b := pba.state.db.Get(prefixLockKey(file))
+	if len(b) == 0 {
+		return nil
+	}
+	hl := HashLock{}
+	json.Unmarshal(b, &hl)
+	return &hl
+}
+
+func (pba *PBApplication) htlcLockActionValidation(dr DeliveryRequest) (uint32, error) {
+	if len(dr.Data.SecretHash) != sha256.Size {
+		return CodeTypeEncodingError, errors.New("The secret hash needs to be a sha256 hash.")
+	}
+	if dr.Data.Timeout <= pba.currentHeight() {
+		return CodeTypeUnauthorized, errors.New("The timeout needs to be after the current block height.")
+	}
+	// the files will be sent when they are claimed, so they need to follow the same rules
+	return pba.sendActionValidation(dr)
+}
+
+func (pba *PBApplication) htlcClaimActionValidation(dr DeliveryRequest) (uint32, error) {
+	if len(dr.Data.Files) == 0 {
+		return CodeTypeUnauthorized, errors.New("There are no files to claim.")
+	}
+	if conf.Conf.Blockchain == conf.OtoOPB {
+		if len(dr.Data.Files) > 1 {
+			return CodeTypeUnauthorized,
+				errors.New("For one to one blockchain, you can not claim more than one file in one delivery.")
+		}
+		fromAddr, _ := dr.FromPubKeyAddress()
+		if pba.state.db.Has(prefixUserKey(fromAddr)) {
+			return CodeTypeUnauthorized, errors.New("For one to one blockchain, you can not use the same key.")
+		}
+	}
+
+	toAddr, _ := dr.FromPubKeyAddress()
+	// the receiver may have been unregistered after the lock
+	code, err := pba.registeredValidation(toAddr)
+	if err != nil {
+		return code, err
+	}
+	secretHash := sha256.Sum256(dr.Data.Secret)
+	for _, v := range dr.Data.Files {
+		hl := pba.getHashLock(v)
+		if hl == nil {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is not locked.")
+		}
+		if hl.To != toAddr {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is not locked for you.")
+		}
+		if hl.Expired {
+			return CodeTypeUnauthorized, errors.New("The lock of the hash " + v + " has expired.")
+		}
+		if !bytes.Equal(hl.SecretHash, secretHash[:]) {
+			return CodeTypeUnauthorized, errors.New("The secret does not unlock the hash " + v + ".")
+		}
+	}
+	// the hashes that were banned or frozen after the lock stay with the sender
+	code, err = pba.bannedFilesValidation(dr.Data.Files)
+	if err != nil {
+		return code, err
+	}
+	return pba.frozenFilesValidation(dr.Data.Files)
+}
+
+func (pba *PBApplication) htlcReclaimActionValidation(dr DeliveryRequest) (uint32, error) {
+	if len(dr.Data.Files) == 0 {
+		return CodeTypeUnauthorized, errors.New("There are no files to reclaim.")
+	}
+	fromAddr, _ := dr.FromPubKeyAddress()
+	for _, v := range dr.Data.Files {
+		hl := pba.getHashLock(v)
+		if hl == nil {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " is not locked.")
+		}
+		if hl.From != fromAddr {
+			return CodeTypeUnauthorized, errors.New("The hash " + v + " was not locked by you.")
+		}
+		if !hl.Expired {
+			return CodeTypeUnauthorized, errors.New("The lock of the hash " + v + " has not expired yet.")
+		}
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) htlcLockActionState(dr DeliveryRequest) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	toAddr, _ := dr.ToPubKeyAddress()
+	hl := HashLock{
+		From:       fromAddr,
+		To:         toAddr,
+		SecretHash: dr.Data.SecretHash,
+		Timeout:    dr.Data.Timeout,
+	}
+	b, _ := json.Marshal(hl)
+	for _, v := range dr.Data.Files {
+		pba.state.db.Set(prefixLockKey(v), b)
+	}
+}
+
+func (pba *PBApplication) htlcClaimActionState(dr DeliveryRequest) {
+	toAddr, _ := dr.FromPubKeyAddress()
+	for _, v := range dr.Data.Files {
+		hl := pba.getHashLock(v)
+		pba.state.db.Delete(prefixLockKey(v))
+		pba.transferFiles(hl.From, toAddr, []string{v})
+	}
+}
+
+func (pba *PBApplication) htlcReclaimActionState(dr DeliveryRequest) {
+	// the files never left the sender, so releasing the lock gives them back
+	for _, v := range dr.Data.Files {
+		pba.state.db.Delete(prefixLockKey(v))
+	}
+}
+
+// moveHashLocks gives the locks of the old address to the new one,
+// so it can reclaim the files that it locked and claim the files that are locked for it.
+func (pba *PBApplication) moveHashLocks(oldAddr, newAddr string) {
+	keys := [][]byte{}
+	locks := []HashLock{}
+	itr := dbm.IteratePrefix(pba.state.db, lockKey)
+	for ; itr.Valid(); itr.Next() {
+		hl := HashLock{}
+		json.Unmarshal(itr.Value(), &hl)
+		if hl.From != oldAddr && hl.To != oldAddr {
+			continue
+		}
+		if hl.From == oldAddr {
+			hl.From = newAddr
+		}
+		if hl.To == oldAddr {
+			hl.To = newAddr
+		}
+		keys = append(keys, itr.Key())
+		locks = append(locks, hl)
+	}
+	itr.Close()
+
+	for i, k := range keys {
+		b, _ := json.Marshal(locks[i])
+		pba.state.db.Set(k, b)
+	}
+}
+
+// expireHashLocks marks the locks that reached their timeout,
+// after that they can not be claimed and the sender can reclaim them.
+func (pba *PBApplication) expireHashLocks(height int64) {
+	keys := [][]byte{}
+	locks := []HashLock{}
+	itr := dbm.IteratePrefix(pba.state.db, lockKey)
+	for ; itr.Valid(); itr.Next() {
+		hl := HashLock{}
+		json.Unmarshal(itr.Value(), &hl)
+		if !hl.Expired && hl.Timeout <= height {
+			hl.Expired = true
+			keys = append(keys, itr.Key())
+			locks = append(locks, hl)
+		}
+	}
+	itr.Close()
+
+	for i, k := range keys {
+		b, _ := json.Marshal(locks[i])
+		pba.state.db.Set(k, b)
+	}
+}
@@ -0,0 +1,106 @@
+package ctrls
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbHtlcClaimSuccessfully(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+	secret := []byte("secret")
+	secretHash := sha256.Sum256(secret)
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	lockDr := utils.createDelivery(t, fromEdKey, DeliveryData{
+		Action:     HTLC_LOCK_ACTION,
+		To:         utils.pubKey(toEdKey),
+		Files:      addDr.Data.Files,
+		SecretHash: secretHash[:],
+		Timeout:    10,
+	})
+	b, _ = json.Marshal(lockDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the sender can not use the locked file
+	sendDr := utils.createSendDelivery(t, fromEdKey, &toEdKey, SEND_ACTION, addDr.Data.Files)
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	claimDr := utils.createDelivery(t, toEdKey, DeliveryData{
+		Action: HTLC_CLAIM_ACTION,
+		Files:  addDr.Data.Files,
+		Secret: []byte("wrong"),
+	})
+	b, _ = json.Marshal(claimDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	claimDr = utils.createDelivery(t, toEdKey, DeliveryData{
+		Action: HTLC_CLAIM_ACTION,
+		Files:  addDr.Data.Files,
+		Secret: secret,
+	})
+	b, _ = json.Marshal(claimDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, toEdKey, REMOVE_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbHtlcReclaimAfterTimeout(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+	secret := []byte("secret")
+	secretHash := sha256.Sum256(secret)
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	lockDr := utils.createDelivery(t, fromEdKey, DeliveryData{
+		Action:     HTLC_LOCK_ACTION,
+		To:         utils.pubKey(toEdKey),
+		Files:      addDr.Data.Files,
+		SecretHash: secretHash[:],
+		Timeout:    2,
+	})
+	b, _ = json.Marshal(lockDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	reclaimDr := utils.createDelivery(t, fromEdKey, DeliveryData{Action: HTLC_RECLAIM_ACTION, Files: addDr.Data.Files})
+	b, _ = json.Marshal(reclaimDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	pba.EndBlock(types.RequestEndBlock{Height: 2})
+
+	claimDr := utils.createDelivery(t, toEdKey, DeliveryData{
+		Action: HTLC_CLAIM_ACTION,
+		Files:  addDr.Data.Files,
+		Secret: secret,
+	})
+	b, _ = json.Marshal(claimDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	b, _ = json.Marshal(reclaimDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, fromEdKey, REMOVE_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
@@ -0,0 +1,143 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+func (pba *PBApplication) getInheritancePlan(addr string) *InheritancePlan {
+	b := pba.state.db.Get(prefixInheritKey(addr))
+	if len(b) == 0 {
+		return nil
+	}
+	ip := InheritancePlan{}
+	json.Unmarshal(b, &ip)
+	return &ip
+}
+
+// recordActivity keeps the height of the last delivery of the account.
+func (pba *PBApplication) recordActivity(dr DeliveryRequest) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	b, _ := json.Marshal(pba.currentHeight())
+	pba.state.db.Set(prefixActivityKey(fromAddr), b)
+}
+
+func (pba *PBApplication) lastActivity(addr string) int64 {
+	var height int64
+	json.Unmarshal(pba.state.db.Get(prefixActivityKey(addr)), &height)
+	return height
+}
+
+// moveInheritance gives the inheritance of the old address to the new one, and the new address
+// becomes the beneficiary of the plans of the old one. The rotation counts as an activity of the new address.
+func (pba *PBApplication) moveInheritance(oldAddr, newAddr string) {
+	if ip := pba.getInheritancePlan(oldAddr); ip != nil {
+		pba.state.db.Delete(prefixInheritKey(oldAddr))
+		if pba.getInheritancePlan(newAddr) == nil && ip.Beneficiary != newAddr {
+			b, _ := json.Marshal(ip)
+			pba.state.db.Set(prefixInheritKey(newAddr), b)
+		}
+	}
+	b, _ := json.Marshal(pba.currentHeight())
+	pba.state.db.Set(prefixActivityKey(newAddr), b)
+
+	keys := [][]byte{}
+	plans := []InheritancePlan{}
+	itr := dbm.IteratePrefix(pba.state.db, inheritKey)
+	for ; itr.Valid(); itr.Next() {
+		ip := InheritancePlan{}
+		json.Unmarshal(itr.Value(), &ip)
+		if ip.Beneficiary == oldAddr {
+			ip.Beneficiary = newAddr
+			keys = append(keys, itr.Key())
+			plans = append(plans, ip)
+		}
+	}
+	itr.Close()
+
+	for i, k := range keys {
+		// the new address can not be its own beneficiary
+		if string(k[len(inheritKey):]) == newAddr {
+			pba.state.db.Delete(k)
+			continue
+		}
+		b, _ := json.Marshal(plans[i])
+		pba.state.db.Set(k, b)
+	}
+}
+
+func (pba *PBApplication) inheritanceActionValidation(dr DeliveryRequest) (uint32, error) {
+	ip := dr.Data.Inheritance
+	if ip == nil {
+		return CodeTypeUnauthorized, errors.New("The inheritance does not exists.")
+	}
+	if ip.Period < 1 {
+		return CodeTypeUnauthorized, errors.New("The period needs to be at least one block.")
+	}
+	fromAddr, _ := dr.FromPubKeyAddress()
+	if ip.Beneficiary == fromAddr {
+		return CodeTypeUnauthorized, errors.New("You can not be your own beneficiary.")
+	}
+	if pba.state.db.Has(prefixRetiredKey(ip.Beneficiary)) {
+		return CodeTypeUnauthorized, errors.New("The address of the beneficiary is retired.")
+	}
+	return pba.registeredValidation(ip.Beneficiary)
+}
+
+// claimInheritanceActionValidation checks that the beneficiary, the From,
+// claims an account that has been inactive for its period.
+func (pba *PBApplication) claimInheritanceActionValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.Testator == nil {
+		return CodeTypeUnauthorized, errors.New("The address of the testator does not exists.")
+	}
+	account := *dr.Data.Testator
+	ip := pba.getInheritancePlan(account)
+	fromAddr, _ := dr.FromPubKeyAddress()
+	if ip == nil || ip.Beneficiary != fromAddr {
+		return CodeTypeUnauthorized, errors.New("You are not the beneficiary of the account " + account + ".")
+	}
+	if pba.state.db.Has(prefixRetiredKey(account)) {
+		return CodeTypeUnauthorized, errors.New("The account " + account + " is retired.")
+	}
+	code, err := pba.frozenAccountValidation(account)
+	if err != nil {
+		return code, err
+	}
+	inactiveUntil := pba.lastActivity(account) + ip.Period
+	if pba.currentHeight() < inactiveUntil {
+		return CodeTypeUnauthorized,
+			errors.New("The account " + account + " is active, it can be claimed at the height " +
+				strconv.FormatInt(inactiveUntil, 10) + ".")
+	}
+	if conf.Conf.Blockchain == conf.OtoOPB && pba.state.db.Has(prefixUserKey(fromAddr)) {
+		return CodeTypeUnauthorized, errors.New("For one to one blockchain, you can not use the same key.")
+	}
+	// the beneficiary may have been unregistered or retired after the plan, and like on a rotation
+	// the files move with their locks, leases and vestings, so only the banned files stop the claim
+	code, err = pba.addressesValidation(account, fromAddr)
+	if err != nil {
+		return code, err
+	}
+	return pba.bannedFilesValidation(pba.getUserFiles(account))
+}
+
+func (pba *PBApplication) inheritanceActionState(dr DeliveryRequest) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	b, _ := json.Marshal(dr.Data.Inheritance)
+	pba.state.db.Set(prefixInheritKey(fromAddr), b)
+}
+
+// claimInheritanceActionState gives the holdings of the account to the beneficiary and retires the account,
+// so the leases of the account return to the beneficiary. The approvals and the guardians of the account
+// are not moved, they would let others use the files of the beneficiary.
+func (pba *PBApplication) claimInheritanceActionState(dr DeliveryRequest) {
+	account := *dr.Data.Testator
+	fromAddr, _ := dr.FromPubKeyAddress()
+	pba.state.db.Delete(prefixInheritKey(account))
+	pba.moveHoldings(account, fromAddr)
+	pba.state.db.Set(prefixRetiredKey(account), []byte(fromAddr))
+}
@@ -0,0 +1,183 @@
+package ctrls
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbClaimInheritanceAfterThePeriod(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	beneficiaryEdKey := crypto.GenPrivKeyEd25519()
+
+	input := [][]byte{[]byte("random1")}
+	addDr := utils.createAddOrRemoveDelivery(t, ownerEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	inheritanceDr := utils.createDelivery(t, ownerEdKey, DeliveryData{
+		Action:      INHERITANCE_ACTION,
+		Inheritance: &InheritancePlan{Beneficiary: utils.address(beneficiaryEdKey), Period: 2},
+	})
+	b, _ = json.Marshal(inheritanceDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	claimDr := utils.createDelivery(t, beneficiaryEdKey, DeliveryData{
+		Action:   CLAIM_INHERITANCE_ACTION,
+		Testator: utils.str(utils.address(ownerEdKey)),
+	})
+	b, _ = json.Marshal(claimDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	pba.Commit()
+	pba.Commit()
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, beneficiaryEdKey, REMOVE_ACTION, input)
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbClaimInheritanceFailWhenTheOwnerIsActive(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	beneficiaryEdKey := crypto.GenPrivKeyEd25519()
+
+	inheritanceDr := utils.createDelivery(t, ownerEdKey, DeliveryData{
+		Action:      INHERITANCE_ACTION,
+		Inheritance: &InheritancePlan{Beneficiary: utils.address(beneficiaryEdKey), Period: 2},
+	})
+	b, _ := json.Marshal(inheritanceDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	pba.Commit()
+	addDr := utils.createAddOrRemoveDelivery(t, ownerEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	pba.Commit()
+	claimDr := utils.createDelivery(t, beneficiaryEdKey, DeliveryData{
+		Action:   CLAIM_INHERITANCE_ACTION,
+		Testator: utils.str(utils.address(ownerEdKey)),
+	})
+	b, _ = json.Marshal(claimDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestSpbClaimInheritanceMovesTheLockedFilesAndRefusesTheBannedFiles(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	lockedEdKey := crypto.GenPrivKeyEd25519()
+	bannedEdKey := crypto.GenPrivKeyEd25519()
+	retiredEdKey := crypto.GenPrivKeyEd25519()
+	beneficiaryEdKey := crypto.GenPrivKeyEd25519()
+	otherBeneficiaryEdKey := crypto.GenPrivKeyEd25519()
+	secretHash := sha256.Sum256([]byte("the secret"))
+	plan := func(owner, beneficiary crypto.PrivKeyEd25519) DeliveryRequest {
+		return utils.createDelivery(t, owner, DeliveryData{
+			Action:      INHERITANCE_ACTION,
+			Inheritance: &InheritancePlan{Beneficiary: utils.address(beneficiary), Period: 1},
+		})
+	}
+	claim := func(beneficiary, owner crypto.PrivKeyEd25519) DeliveryRequest {
+		return utils.createDelivery(t, beneficiary, DeliveryData{
+			Action:   CLAIM_INHERITANCE_ACTION,
+			Testator: utils.str(utils.address(owner)),
+		})
+	}
+
+	lockedAddDr := utils.createAddOrRemoveDelivery(t, lockedEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	bannedAddDr := utils.createAddOrRemoveDelivery(t, bannedEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	retiredAddDr := utils.createAddOrRemoveDelivery(t, retiredEdKey, ADD_ACTION, [][]byte{[]byte("random3")})
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files that will be locked", lockedAddDr, CodeTypeOK},
+		{"add the files that will be banned", bannedAddDr, CodeTypeOK},
+		{"add the files of the account", retiredAddDr, CodeTypeOK},
+		{"plan the inheritance of the locked files", plan(lockedEdKey, beneficiaryEdKey), CodeTypeOK},
+		{"plan the inheritance of the banned files", plan(bannedEdKey, beneficiaryEdKey), CodeTypeOK},
+		{"plan the inheritance to the beneficiary that will retire", plan(retiredEdKey, otherBeneficiaryEdKey), CodeTypeOK},
+		{"lock the files", utils.createDelivery(t, lockedEdKey, DeliveryData{
+			Action:     HTLC_LOCK_ACTION,
+			To:         utils.pubKey(crypto.GenPrivKeyEd25519()),
+			Files:      lockedAddDr.Data.Files,
+			SecretHash: secretHash[:],
+			Timeout:    10,
+		}), CodeTypeOK},
+		{"ban the files", utils.createDelivery(t, adminEdKey, DeliveryData{
+			Action: BAN_ACTION,
+			Reason: utils.str("counterfeit note template"),
+			Files:  bannedAddDr.Data.Files,
+		}), CodeTypeOK},
+	})
+	pba.Commit()
+
+	newEdKey := crypto.GenPrivKeyEd25519()
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"the locked files do not stop the claim", claim(beneficiaryEdKey, lockedEdKey), CodeTypeOK},
+		{"the account can not be claimed twice", claim(beneficiaryEdKey, lockedEdKey), CodeTypeUnauthorized},
+		{"the banned files can not be claimed", claim(beneficiaryEdKey, bannedEdKey), CodeTypeUnauthorized},
+		{"the beneficiary retires its key", utils.coSign(utils.createDelivery(t, otherBeneficiaryEdKey, DeliveryData{
+			Action: ROTATE_ACTION,
+			To:     utils.pubKey(newEdKey),
+		}), newEdKey), CodeTypeOK},
+		{"the retired beneficiary can not claim", claim(otherBeneficiaryEdKey, retiredEdKey), CodeTypeUnauthorized},
+	})
+	// the lock moved with the files to the beneficiary
+	assert.Equal(t, lockedAddDr.Data.Files, pba.getUserFiles(utils.address(beneficiaryEdKey)))
+	assert.Equal(t, utils.address(beneficiaryEdKey), pba.getHashLock(lockedAddDr.Data.Files[0]).From)
+}
+
+func TestSpbClaimInheritanceMovesTheVestingFiles(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	senderEdKey := crypto.GenPrivKeyEd25519()
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	beneficiaryEdKey := crypto.GenPrivKeyEd25519()
+
+	input := [][]byte{[]byte("random1")}
+	addDr := utils.createAddOrRemoveDelivery(t, senderEdKey, ADD_ACTION, input)
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files", addDr, CodeTypeOK},
+		{"plan the inheritance", utils.createDelivery(t, ownerEdKey, DeliveryData{
+			Action:      INHERITANCE_ACTION,
+			Inheritance: &InheritancePlan{Beneficiary: utils.address(beneficiaryEdKey), Period: 1},
+		}), CodeTypeOK},
+		{"send the files to the owner with a vesting", utils.createDelivery(t, senderEdKey, DeliveryData{
+			Action:  SEND_ACTION,
+			To:      utils.pubKey(ownerEdKey),
+			Files:   addDr.Data.Files,
+			Vesting: &VestingLock{Height: 4},
+		}), CodeTypeOK},
+	})
+	pba.Commit()
+	pba.Commit()
+
+	remDr := utils.createAddOrRemoveDelivery(t, beneficiaryEdKey, REMOVE_ACTION, input)
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"the vesting files do not stop the claim", utils.createDelivery(t, beneficiaryEdKey, DeliveryData{
+			Action:   CLAIM_INHERITANCE_ACTION,
+			Testator: utils.str(utils.address(ownerEdKey)),
+		}), CodeTypeOK},
+		{"the files are still vesting for the beneficiary", remDr, CodeTypeUnauthorized},
+	})
+	assert.Equal(t, addDr.Data.Files, pba.getUserFiles(utils.address(beneficiaryEdKey)))
+	assert.True(t, pba.state.db.Has(prefixRetiredKey(utils.address(ownerEdKey))))
+
+	pba.Commit()
+	pba.Commit()
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"the beneficiary removes the files after the vesting", remDr, CodeTypeOK},
+	})
+}
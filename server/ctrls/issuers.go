@@ -0,0 +1,88 @@
+package ctrls
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// getIssuers returns the addresses that can add hashes, all the addresses can add when it is empty.
+func (pba *PBApplication) getIssuers() []string {
+	issuers := []string{}
+	json.Unmarshal(pba.state.db.Get(issuersKey), &issuers)
+	return issuers
+}
+
+func (pba *PBApplication) setIssuers(issuers []string) {
+	if len(issuers) == 0 {
+		pba.state.db.Delete(issuersKey)
+		return
+	}
+	b, _ := json.Marshal(issuers)
+	pba.state.db.Set(issuersKey, b)
+}
+
+// getIssuer returns the address that added the file.
+func (pba *PBApplication) getIssuer(file string) string {
+	return string(pba.state.db.Get(prefixIssuerKey(file)))
+}
+
+func (pba *PBApplication) fileIssuers(files []string) []FileIssuer {
+	fis := []FileIssuer{}
+	for _, v := range files {
+		if issuer := pba.getIssuer(v); len(issuer) > 0 {
+			fis = append(fis, FileIssuer{File: v, Issuer: issuer})
+		}
+	}
+	return fis
+}
+
+func (pba *PBApplication) issuerValidation(dr DeliveryRequest) (uint32, error) {
+	issuers := pba.getIssuers()
+	if len(issuers) == 0 {
+		return CodeTypeOK, nil
+	}
+	fromAddr, _ := dr.FromPubKeyAddress()
+	for _, v := range issuers {
+		if v == fromAddr {
+			return CodeTypeOK, nil
+		}
+	}
+	return CodeTypeUnauthorized, errors.New("Only the issuers can add hashes.")
+}
+
+func (pba *PBApplication) issuersActionValidation(dr DeliveryRequest) (uint32, error) {
+	code, err := pba.adminValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	if dr.Data.Issuers == nil {
+		return CodeTypeUnauthorized, errors.New("The list of the issuers does not exists.")
+	}
+	_, err = upperAddresses("issuer", *dr.Data.Issuers)
+	if err != nil {
+		return CodeTypeEncodingError, err
+	}
+	return CodeTypeOK, nil
+}
+
+// upperAddresses checks that the issuers or the admins are addresses and writes them in upper case,
+// like the addresses of the public keys, so an address in lower case can sign too.
+func upperAddresses(name string, addresses []string) ([]string, error) {
+	upper := []string{}
+	for _, v := range addresses {
+		b, err := hex.DecodeString(v)
+		if err != nil || len(b) != addressLength {
+			return nil, errors.New("The " + name + " " + v + " is not an address.")
+		}
+		upper = append(upper, strings.ToUpper(v))
+	}
+	return upper, nil
+}
+
+func (pba *PBApplication) issuersActionState(dr DeliveryRequest) {
+	issuers, _ := upperAddresses("issuer", *dr.Data.Issuers)
+	pba.setIssuers(issuers)
+	pba.audit(dr, "")
+}
@@ -0,0 +1,111 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbOnlyTheIssuersOfTheGenesisAdd(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	issuerEdKey := crypto.GenPrivKeyEd25519()
+	userEdKey := crypto.GenPrivKeyEd25519()
+	issuerAddr := issuerEdKey.PubKey().Address().String()
+
+	// the issuers of the genesis are addresses, in any case
+	b, _ := json.Marshal(GenesisState{Issuers: []string{"not an address"}})
+	assert.Panics(t, func() { pba.InitChain(types.RequestInitChain{AppStateBytes: b}) })
+	b, _ = json.Marshal(GenesisState{Issuers: []string{strings.ToLower(issuerAddr)}})
+	pba.InitChain(types.RequestInitChain{AppStateBytes: b})
+
+	addDr := utils.createAddOrRemoveDelivery(t, userEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	addDr = utils.createAddOrRemoveDelivery(t, issuerEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the others send and remove what they received
+	sendDr := utils.createSendDelivery(t, issuerEdKey, &userEdKey, SEND_ACTION, addDr.Data.Files)
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	q := utils.querySpb(t, userEdKey, nil, nil)
+	b, _ = json.Marshal(q)
+	qr := QueryResponse{}
+	json.Unmarshal(pba.Query(types.RequestQuery{Data: b}).Value, &qr)
+	assert.Equal(t, []FileIssuer{{File: addDr.Data.Files[0], Issuer: issuerAddr}}, qr.Issuers)
+
+	remDr := utils.createAddOrRemoveDelivery(t, userEdKey, REMOVE_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	b, _ = json.Marshal([]string{issuerAddr})
+	assert.Equal(t, types.ResponseQuery{Code: CodeTypeOK, Value: b},
+		pba.Query(types.RequestQuery{Path: "/issuers"}))
+}
+
+func TestSpbAdminsChangeTheIssuers(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	issuerEdKey := crypto.GenPrivKeyEd25519()
+	userEdKey := crypto.GenPrivKeyEd25519()
+
+	issuers := []string{"not an address"}
+	issuersDr := utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action:  ISSUERS_ACTION,
+		Reason:  utils.str("bank"),
+		Issuers: &issuers,
+	})
+	b, _ := json.Marshal(issuersDr)
+	assert.Equal(t, CodeTypeEncodingError, pba.DeliverTx(b).Code)
+
+	// only the admins set the issuers, an issuer in lower case is the same address
+	issuers = []string{strings.ToLower(issuerEdKey.PubKey().Address().String())}
+	issuersDr = utils.createDelivery(t, userEdKey, DeliveryData{
+		Action:  ISSUERS_ACTION,
+		Reason:  utils.str("bank"),
+		Issuers: &issuers,
+	})
+	b, _ = json.Marshal(issuersDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	issuersDr = utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action:  ISSUERS_ACTION,
+		Reason:  utils.str("bank"),
+		Issuers: &issuers,
+	})
+	b, _ = json.Marshal(issuersDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	addDr := utils.createAddOrRemoveDelivery(t, userEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	issuerAddDr := utils.createAddOrRemoveDelivery(t, issuerEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	b, _ = json.Marshal(issuerAddDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// an empty list lets everyone add
+	issuers = []string{}
+	issuersDr = utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action:  ISSUERS_ACTION,
+		Reason:  utils.str("open"),
+		Issuers: &issuers,
+	})
+	b, _ = json.Marshal(issuersDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
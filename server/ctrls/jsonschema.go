@@ -0,0 +1,235 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// JsonSchema is the part of the JSON Schema that the chain validates:
+// type, required, properties, additionalProperties, items, enum,
+// minimum, maximum, minLength, maxLength and pattern.
+// The other keywords are rejected, except the annotations that do not validate.
+type JsonSchema struct {
+	Type                 interface{}            `json:"type,omitempty"` // a type or a list of types
+	Required             []string               `json:"required,omitempty"`
+	Properties           map[string]*JsonSchema `json:"properties,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *JsonSchema            `json:"items,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+}
+
+// schemaKeywords are the keywords that a schema can have.
+var schemaKeywords = map[string]bool{
+	"type": true, "required": true, "properties": true, "additionalProperties": true, "items": true,
+	"enum": true, "minimum": true, "maximum": true, "minLength": true, "maxLength": true, "pattern": true,
+	// the annotations
+	"$schema": true, "title": true, "description": true,
+}
+
+func parseJsonSchema(b []byte) (*JsonSchema, error) {
+	js := JsonSchema{}
+	err := json.Unmarshal(b, &js)
+	if err != nil {
+		return nil, errors.New("The schema is not a json schema: " + err.Error())
+	}
+	// a keyword that the chain does not know would be ignored, so the json would pass without it
+	err = checkKeywords(b)
+	if err != nil {
+		return nil, err
+	}
+	return &js, js.check()
+}
+
+// checkKeywords verifies that the schema and its properties and items are objects
+// and have only the known keywords.
+func checkKeywords(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return errors.New("The schema is not a json schema: " + err.Error())
+	}
+	// a null unmarshals to a nil map and a nil subschema
+	if raw == nil {
+		return errors.New("The schema needs to be an object, not null.")
+	}
+	keys := []string{}
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !schemaKeywords[k] {
+			return errors.New("The schema has the unknown keyword " + strconv.Quote(k) + ".")
+		}
+	}
+	if b, ok := raw["properties"]; ok {
+		var properties map[string]json.RawMessage
+		if err := json.Unmarshal(b, &properties); err != nil || properties == nil {
+			return errors.New("The properties of the schema need to be an object.")
+		}
+		names := []string{}
+		for k := range properties {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			if err := checkKeywords(properties[k]); err != nil {
+				return err
+			}
+		}
+	}
+	if b, ok := raw["items"]; ok {
+		return checkKeywords(b)
+	}
+	return nil
+}
+
+// check verifies the types and the patterns of the schema before it is used.
+func (js *JsonSchema) check() error {
+	for _, v := range js.types() {
+		switch v {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return errors.New("The schema has the unknown type " + v + ".")
+		}
+	}
+	if len(js.Pattern) > 0 {
+		if _, err := regexp.Compile(js.Pattern); err != nil {
+			return errors.New("The schema has a wrong pattern: " + err.Error())
+		}
+	}
+	for _, v := range js.Properties {
+		if err := v.check(); err != nil {
+			return err
+		}
+	}
+	if js.Items != nil {
+		return js.Items.check()
+	}
+	return nil
+}
+
+func (js *JsonSchema) types() []string {
+	switch t := js.Type.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := []string{}
+		for _, v := range t {
+			s, _ := v.(string)
+			types = append(types, s)
+		}
+		return types
+	}
+	return nil
+}
+
+func jsonType(v interface{}) string {
+	switch n := v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		if n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// validate returns the first error of the value, the path is the location of the value.
+func (js *JsonSchema) validate(v interface{}, path string) error {
+	if types := js.types(); len(types) > 0 {
+		vt := jsonType(v)
+		matches := false
+		for _, t := range types {
+			if t == vt || (t == "number" && vt == "integer") {
+				matches = true
+			}
+		}
+		if !matches {
+			return errors.New(path + " needs to be " + strconv.Quote(types[0]) + ".")
+		}
+	}
+	if len(js.Enum) > 0 {
+		found := false
+		for _, e := range js.Enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+			}
+		}
+		if !found {
+			return errors.New(path + " is not one of the enum.")
+		}
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for _, r := range js.Required {
+			if _, ok := value[r]; !ok {
+				return errors.New(path + " needs the property " + strconv.Quote(r) + ".")
+			}
+		}
+		// the properties are validated in order, so the error is the same on every node
+		keys := []string{}
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ps, ok := js.Properties[k]
+			if !ok {
+				if js.AdditionalProperties != nil && !*js.AdditionalProperties {
+					return errors.New(path + " can not have the property " + strconv.Quote(k) + ".")
+				}
+				continue
+			}
+			if err := ps.validate(value[k], path+"."+k); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if js.Items != nil {
+			for i, item := range value {
+				if err := js.Items.validate(item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(value)
+		if js.MinLength != nil && length < *js.MinLength {
+			return errors.New(path + " is shorter than " + strconv.Itoa(*js.MinLength) + ".")
+		}
+		if js.MaxLength != nil && length > *js.MaxLength {
+			return errors.New(path + " is longer than " + strconv.Itoa(*js.MaxLength) + ".")
+		}
+		if len(js.Pattern) > 0 && !regexp.MustCompile(js.Pattern).MatchString(value) {
+			return errors.New(path + " does not match the pattern " + js.Pattern + ".")
+		}
+	case float64:
+		if js.Minimum != nil && value < *js.Minimum {
+			return errors.New(path + " is less than the minimum.")
+		}
+		if js.Maximum != nil && value > *js.Maximum {
+			return errors.New(path + " is more than the maximum.")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+func (pba *PBApplication) leaseActionValidation(dr DeliveryRequest) (uint32, error) {
+	if conf.Conf.Blockchain == conf.OtoOPB {
+		return CodeTypeUnauthorized,
+			errors.New("For one to one blockchain, you can not lease because the hash returns to the same key.")
+	}
+	if dr.Data.LeaseExpiry <= pba.currentHeight() {
+		return CodeTypeUnauthorized, errors.New("The lease expiry needs to be after the current block height.")
+	}
+	return pba.sendActionValidation(dr)
+}
+
+func (pba *PBApplication) leaseActionState(dr DeliveryRequest) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	toAddr, _ := dr.ToPubKeyAddress()
+	l := Lease{
+		Owner:  fromAddr,
+		Expiry: dr.Data.LeaseExpiry,
+	}
+	b, _ := json.Marshal(l)
+	pba.transferFiles(fromAddr, toAddr, dr.Data.Files)
+	for _, v := range dr.Data.Files {
+		pba.state.db.Set(prefixLeaseKey(v), b)
+	}
+}
+
+// returnLeases gives back the leased files to their owners at the end of the block of their expiry.
+func (pba *PBApplication) returnLeases(height int64) {
+	files := []string{}
+	leases := []Lease{}
+	itr := dbm.IteratePrefix(pba.state.db, leaseKey)
+	for ; itr.Valid(); itr.Next() {
+		l := Lease{}
+		json.Unmarshal(itr.Value(), &l)
+		if l.Expiry <= height {
+			files = append(files, string(itr.Key()[len(leaseKey):]))
+			leases = append(leases, l)
+		}
+	}
+	itr.Close()
+
+	for i, v := range files {
+		pba.state.db.Delete(prefixLeaseKey(v))
+		// the lessee could have rotated its key, so the file is taken from its current holder
+		holder := string(pba.state.db.Get(prefixFileKey(v)))
+		pba.transferFiles(holder, pba.activeAddress(leases[i].Owner), []string{v})
+	}
+}
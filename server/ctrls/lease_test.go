@@ -0,0 +1,80 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbLeaseReturnsToTheOwner(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	lesseeEdKey := crypto.GenPrivKeyEd25519()
+	thirdEdKey := crypto.GenPrivKeyEd25519()
+
+	input := [][]byte{[]byte("random1")}
+	addDr := utils.createAddOrRemoveDelivery(t, ownerEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the Timeout is only for the htlc-lock
+	leaseDr := utils.createDelivery(t, ownerEdKey, DeliveryData{
+		Action:  LEASE_ACTION,
+		To:      utils.pubKey(lesseeEdKey),
+		Files:   addDr.Data.Files,
+		Timeout: 5,
+	})
+	b, _ = json.Marshal(leaseDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	leaseDr = utils.createDelivery(t, ownerEdKey, DeliveryData{
+		Action:      LEASE_ACTION,
+		To:          utils.pubKey(lesseeEdKey),
+		Files:       addDr.Data.Files,
+		LeaseExpiry: 5,
+	})
+	b, _ = json.Marshal(leaseDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the lessee can not give away or remove the leased hash
+	sendDr := utils.createSendDelivery(t, lesseeEdKey, &thirdEdKey, SEND_ACTION, addDr.Data.Files)
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, lesseeEdKey, REMOVE_ACTION, input)
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	pba.EndBlock(types.RequestEndBlock{Height: 5})
+
+	remDr = utils.createAddOrRemoveDelivery(t, ownerEdKey, REMOVE_ACTION, input)
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbLeaseFailOnExpiryInThePast(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	lesseeEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, ownerEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	leaseDr := utils.createDelivery(t, ownerEdKey, DeliveryData{
+		Action:      LEASE_ACTION,
+		To:          utils.pubKey(lesseeEdKey),
+		Files:       addDr.Data.Files,
+		LeaseExpiry: 1,
+	})
+	b, _ = json.Marshal(leaseDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
@@ -1,7 +1,11 @@
 package ctrls
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/tendermint/go-crypto"
@@ -12,29 +16,272 @@ const (
 	CodeTypeEncodingError uint32 = 1
 	CodeTypeBadNonce      uint32 = 2
 	CodeTypeUnauthorized  uint32 = 3
+	CodeTypePaused        uint32 = 5 // the 4 is the error of the client
 )
 
+// addressLength is the bytes of an address, the public keys are longer.
+const addressLength = 20
+
 type ActionStruct string
 
 const (
 	ADD_ACTION    = ActionStruct("add")
 	REMOVE_ACTION = ActionStruct("remove")
 	SEND_ACTION   = ActionStruct("send")
+	SWAP_ACTION   = ActionStruct("swap")
+
+	HTLC_LOCK_ACTION    = ActionStruct("htlc-lock")
+	HTLC_CLAIM_ACTION   = ActionStruct("htlc-claim")
+	HTLC_RECLAIM_ACTION = ActionStruct("htlc-reclaim")
+
+	ESCROW_ACTION         = ActionStruct("escrow")
+	ESCROW_RELEASE_ACTION = ActionStruct("escrow-release")
+	ESCROW_REFUND_ACTION  = ActionStruct("escrow-refund")
+
+	MULTISIG_CREATE_ACTION = ActionStruct("multisig-create")
+
+	ROTATE_ACTION = ActionStruct("rotate")
+
+	GUARDIANS_ACTION       = ActionStruct("guardians")
+	RECOVERY_ACTION        = ActionStruct("recovery")
+	RECOVERY_CANCEL_ACTION = ActionStruct("recovery-cancel")
+
+	INHERITANCE_ACTION       = ActionStruct("inheritance")
+	CLAIM_INHERITANCE_ACTION = ActionStruct("claim-inheritance")
+
+	LEASE_ACTION = ActionStruct("lease")
+
+	APPROVE_ACTION        = ActionStruct("approve")
+	APPROVE_REVOKE_ACTION = ActionStruct("approve-revoke")
+	SEND_FROM_ACTION      = ActionStruct("send-from")
+
+	SUBKEY_ACTION        = ActionStruct("subkey")
+	SUBKEY_REVOKE_ACTION = ActionStruct("subkey-revoke")
+
+	FREEZE_ACTION          = ActionStruct("freeze")
+	UNFREEZE_ACTION        = ActionStruct("unfreeze")
+	FORCED_TRANSFER_ACTION = ActionStruct("forced-transfer")
+
+	PAUSE_ACTION   = ActionStruct("pause")
+	UNPAUSE_ACTION = ActionStruct("unpause")
+
+	BAN_ACTION   = ActionStruct("ban")
+	UNBAN_ACTION = ActionStruct("unban")
+
+	REGISTER_ACTION   = ActionStruct("register")
+	UNREGISTER_ACTION = ActionStruct("unregister")
+
+	REGISTER_NAME_ACTION = ActionStruct("register-name")
+	TRANSFER_NAME_ACTION = ActionStruct("transfer-name")
+
+	BATCH_ACTION = ActionStruct("batch")
+
+	SPLIT_ACTION = ActionStruct("split")
+	MERGE_ACTION = ActionStruct("merge")
+
+	ISSUERS_ACTION = ActionStruct("issuers")
+
+	SCHEMA_REGISTER_ACTION   = ActionStruct("schema-register")
+	SCHEMA_UNREGISTER_ACTION = ActionStruct("schema-unregister")
+
+	ALERTS_ACTION = ActionStruct("alerts")
 )
 
 type DeliveryData struct {
-	From   []byte  // public key
-	To     *[]byte // public key
-	Action ActionStruct
-	Files  []string
+	From    []byte  // public key
+	To      *[]byte // public key, or the address of the receiver for the sends on SPB
+	Action  ActionStruct
+	Files   []string
+	ToFiles []string `json:",omitempty"` // the files that the receiver gives back on a swap
+
+	SecretHash []byte `json:",omitempty"` // sha256 of the secret for the htlc-lock
+	Timeout    int64  `json:",omitempty"` // the block height that the htlc-lock expires
+	Secret     []byte `json:",omitempty"` // the secret that the receiver reveals on htlc-claim
+
+	Arbiter *[]byte `json:",omitempty"` // public key of the arbiter for the escrow
+
+	FromMultisig *string             `json:",omitempty"` // the multisig address that the From signs for
+	ToMultisig   *string             `json:",omitempty"` // the multisig address of the receiver instead of the To
+	Multisig     *MultisigDefinition `json:",omitempty"` // the definition for the multisig-create
+
+	Guardians   *GuardianSet `json:",omitempty"` // the guardians that the From registers
+	LostAccount *string      `json:",omitempty"` // the address that the guardians recover
+
+	Inheritance *InheritancePlan `json:",omitempty"` // the beneficiary that the From designates
+	Testator    *string          `json:",omitempty"` // the address whose inheritance the beneficiary claims
+
+	Vesting *VestingLock `json:",omitempty"` // the lock on the files that the receiver gets on a send
+
+	LeaseExpiry int64 `json:",omitempty"` // the block height that the leased files return to the From
+
+	Approval *Approval `json:",omitempty"` // the permission that the From gives on approve
+	Owner    *string   `json:",omitempty"` // the address that the delegate sends from
+
+	FromAccount *string `json:",omitempty"` // the account that the From signs for as a sub-key
+	SubKey      *SubKey `json:",omitempty"` // the sub-key that the From registers or revokes
+
+	Account     *string `json:",omitempty"` // the address that the admin acts on
+	Reason      *string `json:",omitempty"` // the reason of an admin action for the audit log
+	ForceRemove bool    `json:",omitempty"` // the ban removes the banned files from their owners
+
+	Name *string `json:",omitempty"` // the name for register-name and transfer-name
+
+	Operations []DeliveryData `json:",omitempty"` // the operations of a batch in order, the From signs them all
+
+	Recipients []Recipient `json:",omitempty"` // the receivers of a send instead of the To and the Files
+
+	NewFiles []string `json:",omitempty"` // the notes that the split or the merge gives to the From for the Files
+
+	Issuers *[]string `json:",omitempty"` // the addresses that can add hashes, an empty list lets everyone add
+
+	Schema *string `json:",omitempty"` // the hash of the registered json schema that the added json files follow
+
+	Webhook *string `json:",omitempty"` // the url that gets the alerts of the monitor for the From, an empty url unsubscribes
+}
+
+// FileIssuer is the address that added a file in a query.
+type FileIssuer struct {
+	File   string
+	Issuer string
+}
+
+// FileSchema is the registered json schema that a file was validated against when it was added.
+type FileSchema struct {
+	File   string
+	Schema string
+}
+
+// Recipient is a receiver of a send with many receivers and the files that it gets.
+type Recipient struct {
+	To    []byte // public key, or the address of the receiver on SPB
+	Files []string
+}
+
+// NameRecord is the public key that a name resolves to.
+type NameRecord struct {
+	Name   string
+	PubKey []byte
+}
+
+// AuditEntry is an admin action in the audit log, the entries are never changed or removed.
+type AuditEntry struct {
+	Height  int64
+	Action  ActionStruct
+	Admins  []string
+	Files   []string `json:",omitempty"`
+	Account string   `json:",omitempty"`
+	To      string   `json:",omitempty"`
+	Issuers []string `json:",omitempty"`
+	Reason  string
+}
+
+// AdminSet is the admins of the genesis, an admin action needs Threshold of them to sign.
+type AdminSet struct {
+	Addresses []string
+	Threshold int
+}
+
+// SubKey is a key that signs for the Account with the FromAccount,
+// but only for the Actions, or for all the actions when they are empty.
+// It can add up to MaxAdds hashes until the block of the Expiry,
+// a zero MaxAdds or Expiry is not a limit.
+type SubKey struct {
+	PubKey  []byte
+	Actions []ActionStruct `json:",omitempty"`
+	MaxAdds int            `json:",omitempty"`
+	Expiry  int64          `json:",omitempty"`
+	Account string         `json:",omitempty"` // the account of the sub-key, it is set by the chain
+	Added   int            `json:",omitempty"` // the hashes that the sub-key has added, it is set by the chain
+}
+
+// Approval lets the Delegate send the Files of the owner with send-from,
+// or any of its files when the Files are empty.
+// The Delegate can send up to Count hashes until the block of the Expiry,
+// a zero Count or Expiry is not a limit.
+type Approval struct {
+	Delegate string
+	Files    []string `json:",omitempty"`
+	Count    int      `json:",omitempty"`
+	Expiry   int64    `json:",omitempty"`
+}
+
+// VestingLock keeps the files in the account of the receiver
+// until the block of the Height and the block time of the Time (unix seconds).
+// A zero Height or Time is not part of the lock.
+type VestingLock struct {
+	Height int64 `json:",omitempty"`
+	Time   int64 `json:",omitempty"`
+}
+
+// Locked returns if the lock holds on a block with that height and time.
+func (vl VestingLock) Locked(height, time int64) bool {
+	return (vl.Height > 0 && height < vl.Height) || (vl.Time > 0 && time < vl.Time)
+}
+
+// FileVesting is the vesting lock of a file in a query.
+type FileVesting struct {
+	File  string
+	Until VestingLock
+}
+
+// InheritancePlan is the address that can claim the files of the account,
+// when the account has not delivered anything for Period blocks.
+type InheritancePlan struct {
+	Beneficiary string
+	Period      int64
+}
+
+// GuardianSet is the addresses that can recover an account when Threshold of them sign,
+// the account can cancel the recovery for Delay blocks.
+type GuardianSet struct {
+	Addresses []string
+	Threshold int
+	Delay     int64
+}
+
+// Recovery is a recovery that the guardians have started,
+// the files move to the NewAddr at the end of the block of the Height.
+type Recovery struct {
+	NewAddr string
+	Height  int64
+}
+
+// MultisigDefinition is an owner that needs the signatures of Threshold from the PubKeys.
+type MultisigDefinition struct {
+	Threshold int
+	PubKeys   [][]byte
+}
+
+// Address is the sha256 of the definition, in the same form as the addresses of the public keys.
+func (md *MultisigDefinition) Address() string {
+	b, _ := json.Marshal(md)
+	h := sha256.Sum256(b)
+	return strings.ToUpper(hex.EncodeToString(h[:20]))
+}
+
+// KeySignature is the signature of the delivery's data from a key other than the sender.
+type KeySignature struct {
+	PubKey    []byte
+	Signature []byte
 }
 
 type DeliveryRequest struct {
-	Signature []byte //hex
-	Data      DeliveryData
+	Signature   []byte         //hex
+	CoSignature []byte         `json:",omitempty"` // the signature of the receiver on a swap or a rotate
+	Signatures  []KeySignature `json:",omitempty"` // the signatures of the other parties
+	Data        DeliveryData
 }
 
+// FromPubKeyAddress returns the address that the delivery is for,
+// it is the multisig address when the From signs for a multisig
+// and the account when the From signs as a sub-key.
 func (dr *DeliveryRequest) FromPubKeyAddress() (string, error) {
+	if dr.Data.FromMultisig != nil {
+		return *dr.Data.FromMultisig, nil
+	}
+	if dr.Data.FromAccount != nil {
+		return *dr.Data.FromAccount, nil
+	}
 	pubkey, err := crypto.PubKeyFromBytes(dr.Data.From)
 	if err != nil {
 		return "", err
@@ -42,10 +289,21 @@ func (dr *DeliveryRequest) FromPubKeyAddress() (string, error) {
 	return pubkey.Address().String(), nil
 }
 
+// ToIsAddress checks if the To is the address of the receiver instead of its public key.
+func (dr *DeliveryRequest) ToIsAddress() bool {
+	return dr.Data.To != nil && len(*dr.Data.To) == addressLength
+}
+
 func (dr *DeliveryRequest) ToPubKeyAddress() (string, error) {
+	if dr.Data.ToMultisig != nil {
+		return *dr.Data.ToMultisig, nil
+	}
 	if dr.Data.To == nil {
 		return "", errors.New("The public key of the receiver is empty.")
 	}
+	if dr.ToIsAddress() {
+		return strings.ToUpper(hex.EncodeToString(*dr.Data.To)), nil
+	}
 	pubkey, err := crypto.PubKeyFromBytes(*dr.Data.To)
 	if err != nil {
 		return "", err
@@ -53,6 +311,54 @@ func (dr *DeliveryRequest) ToPubKeyAddress() (string, error) {
 	return pubkey.Address().String(), nil
 }
 
+// HashLock is the state of a file that is locked by an htlc-lock,
+// until it is claimed by the receiver or reclaimed by the sender after it expires.
+type HashLock struct {
+	From       string // address
+	To         string // address
+	SecretHash []byte
+	Timeout    int64
+	Expired    bool
+}
+
+// Escrow is the state of a file that the chain holds for the seller and the buyer,
+// the addresses of the three parties are kept.
+type Escrow struct {
+	Seller  string
+	Buyer   string
+	Arbiter string
+}
+
+// SignerAddresses returns the addresses of the keys that have signed the data,
+// the sender is included because its signature is checked first.
+func (dr *DeliveryRequest) SignerAddresses() map[string]bool {
+	signers := map[string]bool{}
+	if from, err := crypto.PubKeyFromBytes(dr.Data.From); err == nil {
+		signers[from.Address().String()] = true
+	}
+	b, _ := json.Marshal(dr.Data)
+	for _, ks := range dr.Signatures {
+		pubk, err := crypto.PubKeyFromBytes(ks.PubKey)
+		if err != nil {
+			continue
+		}
+		sig, err := crypto.SignatureFromBytes(ks.Signature)
+		if err != nil {
+			continue
+		}
+		if pubk.VerifyBytes(b, sig) {
+			signers[pubk.Address().String()] = true
+		}
+	}
+	return signers
+}
+
+// Lease is a file that returns to its Owner at the end of the block of the Expiry.
+type Lease struct {
+	Owner  string
+	Expiry int64
+}
+
 type SpbQueryData struct {
 	From     []byte
 	Nonce    string
@@ -79,5 +385,10 @@ type OtopbQuery struct {
 }
 
 type QueryResponse struct {
-	Files []string
+	Files     []string
+	InEscrow  []string      `json:",omitempty"` // the files in escrow that the user is a party
+	Vesting   []FileVesting `json:",omitempty"` // the files that are still locked by a vesting
+	Approvals []Approval    `json:",omitempty"` // the active approvals of the user
+	Issuers   []FileIssuer  `json:",omitempty"` // the issuers of the files
+	Schemas   []FileSchema  `json:",omitempty"` // the schemas that the json files were validated against
 }
@@ -0,0 +1,234 @@
+package ctrls
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ipfs/go-ipfs-api"
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+)
+
+// alertTimeout is the time that a webhook has to answer an alert.
+const alertTimeout = 10 * time.Second
+
+// MonitorMetrics are the counters of the monitor, the last round is the last walk of the hashes.
+type MonitorMetrics struct {
+	Rounds       int64
+	Hashes       int   // the hashes of the last round
+	Available    int   // the hashes of the last round that the IPFS returned
+	Unavailable  int   // the hashes of the last round that the IPFS did not return in the timeout
+	LastRound    int64 // the unix seconds that the last round finished
+	LastDuration int64 // the milliseconds of the last round
+	AlertsSent   int64
+	AlertsFailed int64
+}
+
+// UnavailableHash is a hash that the IPFS did not return since the unix seconds of Since.
+type UnavailableHash struct {
+	File  string
+	Owner string
+	Since int64
+}
+
+// MonitorReport is the query of the monitor, the hashes are in order.
+type MonitorReport struct {
+	Metrics     MonitorMetrics
+	Unavailable []UnavailableHash
+}
+
+// Alert is what the webhook of an owner gets when its hashes become unreachable or reachable again.
+type Alert struct {
+	Owner       string
+	Unavailable []string `json:",omitempty"`
+	Available   []string `json:",omitempty"` // the hashes that are reachable again
+	Time        int64
+}
+
+// Monitor walks the hashes of the chain and checks that the IPFS returns them.
+// It runs outside the consensus like the pinner, it only reads the state of the last commit.
+type Monitor struct {
+	mtx         sync.Mutex
+	timeout     time.Duration
+	metrics     MonitorMetrics
+	unavailable map[string]UnavailableHash
+	now         func() time.Time
+	send        func(webhook string, alert *Alert) error
+}
+
+func newMonitor(timeout time.Duration) *Monitor {
+	return &Monitor{timeout: timeout, unavailable: map[string]UnavailableHash{}, now: time.Now, send: sendAlert}
+}
+
+// EnableMonitor starts the monitor, it walks the hashes every interval
+// and each hash has the timeout to be returned by the IPFS.
+func (pba *PBApplication) EnableMonitor(interval, timeout time.Duration) {
+	pba.monitor = newMonitor(timeout)
+	pba.saveCommitted()
+	go func() {
+		for {
+			pba.monitorRound()
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// monitorRound checks all the hashes once and alerts the owners of the hashes that changed.
+func (pba *PBApplication) monitorRound() {
+	m := pba.monitor
+	start := m.now()
+	cs := pba.lastCommitted()
+	owners := cs.owners
+	sh := shell.NewShell(conf.Conf.IpfsConnection)
+	sh.SetTimeout(m.timeout)
+
+	unavailable := map[string]UnavailableHash{}
+	for file, owner := range owners {
+		if _, err := sh.BlockGet(file); err == nil {
+			continue
+		}
+		uh := UnavailableHash{File: file, Owner: owner, Since: start.Unix()}
+		m.mtx.Lock()
+		if old, ok := m.unavailable[file]; ok && old.Owner == owner {
+			uh.Since = old.Since
+		}
+		m.mtx.Unlock()
+		unavailable[file] = uh
+	}
+
+	// the alerts are only for the changes, so the owners do not get the same alert on each round
+	alerts := map[string]*Alert{}
+	alertOf := func(owner string) *Alert {
+		if _, ok := alerts[owner]; !ok {
+			alerts[owner] = &Alert{Owner: owner, Time: start.Unix()}
+		}
+		return alerts[owner]
+	}
+	m.mtx.Lock()
+	for file, uh := range unavailable {
+		if _, ok := m.unavailable[file]; !ok {
+			alertOf(uh.Owner).Unavailable = append(alertOf(uh.Owner).Unavailable, file)
+		}
+	}
+	for file, uh := range m.unavailable {
+		// the removed hashes are not reachable again, only the hashes that the owner still has
+		if _, ok := unavailable[file]; !ok && owners[file] == uh.Owner {
+			alertOf(uh.Owner).Available = append(alertOf(uh.Owner).Available, file)
+		}
+	}
+	m.unavailable = unavailable
+	m.metrics.Rounds += 1
+	m.metrics.Hashes = len(owners)
+	m.metrics.Unavailable = len(unavailable)
+	m.metrics.Available = len(owners) - len(unavailable)
+	m.metrics.LastRound = m.now().Unix()
+	m.metrics.LastDuration = int64(m.now().Sub(start) / time.Millisecond)
+	m.mtx.Unlock()
+
+	for owner, alert := range alerts {
+		webhook := cs.webhooks[owner]
+		if len(webhook) == 0 {
+			continue
+		}
+		sort.Strings(alert.Unavailable)
+		sort.Strings(alert.Available)
+		err := m.send(webhook, alert)
+		m.mtx.Lock()
+		if err != nil {
+			log.Println("The alert for " + owner + " failed: " + err.Error())
+			m.metrics.AlertsFailed += 1
+		} else {
+			m.metrics.AlertsSent += 1
+		}
+		m.mtx.Unlock()
+	}
+}
+
+// publicDialer connects only to the public addresses, the name of a webhook can have
+// a private address even when the url was public at the alerts action.
+var publicDialer = &net.Dialer{
+	Timeout: alertTimeout,
+	Control: func(network, address string, c syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+			return errors.New("the webhook has the private address " + host)
+		}
+		return nil
+	},
+}
+
+func sendAlert(webhook string, alert *Alert) error {
+	b, _ := json.Marshal(alert)
+	client := http.Client{
+		Timeout:   alertTimeout,
+		Transport: &http.Transport{DialContext: publicDialer.DialContext},
+		// a redirect could lead to a url that the alerts action did not accept
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return errors.New("the webhook can not redirect")
+		},
+	}
+	resp, err := client.Post(webhook, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("the webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// monitorReport returns the metrics and the unavailable hashes, only of the owner when it is not empty.
+// The report of all the owners is only for the operator, like for the metrics.
+func (pba *PBApplication) monitorReport(owner string) MonitorReport {
+	m := pba.monitor
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	report := MonitorReport{Metrics: m.metrics, Unavailable: []UnavailableHash{}}
+	for _, v := range m.unavailable {
+		if len(owner) == 0 || v.Owner == owner {
+			report.Unavailable = append(report.Unavailable, v)
+		}
+	}
+	sort.Slice(report.Unavailable, func(i, j int) bool {
+		return report.Unavailable[i].File < report.Unavailable[j].File
+	})
+	return report
+}
+
+// ServeMetrics writes the metrics of the monitor in the text format of Prometheus.
+func (pba *PBApplication) ServeMetrics(w http.ResponseWriter, r *http.Request) {
+	if pba.monitor == nil {
+		http.Error(w, "The monitor is not enabled.", http.StatusNotFound)
+		return
+	}
+	m := pba.monitorReport("").Metrics
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	metrics := []struct {
+		name, kind, help string
+		value            int64
+	}{
+		{"pb_monitor_rounds_total", "counter", "The rounds of the monitor.", m.Rounds},
+		{"pb_monitor_hashes", "gauge", "The hashes that the last round checked.", int64(m.Hashes)},
+		{"pb_monitor_available_hashes", "gauge", "The hashes that the IPFS returned in the last round.", int64(m.Available)},
+		{"pb_monitor_unavailable_hashes", "gauge", "The hashes that the IPFS did not return in the last round.", int64(m.Unavailable)},
+		{"pb_monitor_last_round_timestamp_seconds", "gauge", "The unix time that the last round finished.", m.LastRound},
+		{"pb_monitor_last_round_duration_milliseconds", "gauge", "The duration of the last round.", m.LastDuration},
+		{"pb_monitor_alerts_sent_total", "counter", "The alerts that the webhooks accepted.", m.AlertsSent},
+		{"pb_monitor_alerts_failed_total", "counter", "The alerts that the webhooks did not accept.", m.AlertsFailed},
+	}
+	for _, v := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", v.name, v.help, v.name, v.kind, v.name, v.value)
+	}
+}
@@ -0,0 +1,163 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+func (f forTestUtils) queryMonitor(t *testing.T, pba *PBApplication, from crypto.PrivKeyEd25519, userAddr *string) types.ResponseQuery {
+	b, _ := json.Marshal(f.querySpb(t, from, nil, userAddr))
+	return pba.Query(types.RequestQuery{Path: "/monitor", Data: b})
+}
+
+func TestSpbMonitorReportsAndAlertsTheUnavailableHashes(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	pba.monitor = newMonitor(time.Second)
+	pba.monitor.now = func() time.Time { return time.Unix(1000, 0) }
+	utils := forTestUtils{}
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	ownerAddr := ownerEdKey.PubKey().Address().String()
+
+	alerts := []Alert{}
+	webhooks := []string{}
+	pba.monitor.send = func(webhook string, alert *Alert) error {
+		webhooks = append(webhooks, webhook)
+		alerts = append(alerts, *alert)
+		return nil
+	}
+
+	alertsDr := utils.createDelivery(t, ownerEdKey, DeliveryData{Action: ALERTS_ACTION, Webhook: utils.str("https://example.com/alerts")})
+	b, _ := json.Marshal(alertsDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	addDr := utils.createAddOrRemoveDelivery(t, ownerEdKey, ADD_ACTION, [][]byte{[]byte("monitor1")})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	// the IPFS lost the hash after the add
+	missing := "QmMissing"
+	pba.state.db.Set(prefixFileKey(missing), []byte(ownerAddr))
+
+	// the monitor reads only the committed state
+	pba.monitorRound()
+	assert.Equal(t, 0, len(alerts))
+	pba.Commit()
+
+	pba.monitorRound()
+	assert.Equal(t, []Alert{{Owner: ownerAddr, Unavailable: []string{missing}, Time: 1000}}, alerts)
+	assert.Equal(t, []string{"https://example.com/alerts"}, webhooks)
+
+	// the next round does not alert again
+	pba.monitor.now = func() time.Time { return time.Unix(2000, 0) }
+	pba.monitorRound()
+	assert.Equal(t, 1, len(alerts))
+
+	res := utils.queryMonitor(t, pba, ownerEdKey, nil)
+	assert.Equal(t, CodeTypeOK, res.Code)
+	report := MonitorReport{}
+	json.Unmarshal(res.Value, &report)
+	assert.Equal(t, []UnavailableHash{{File: missing, Owner: ownerAddr, Since: 1000}}, report.Unavailable)
+	assert.Equal(t, MonitorMetrics{Rounds: 3, Hashes: 2, Available: 1, Unavailable: 1,
+		LastRound: 2000, AlertsSent: 1}, report.Metrics)
+
+	rec := httptest.NewRecorder()
+	pba.ServeMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.True(t, strings.Contains(rec.Body.String(), "\npb_monitor_unavailable_hashes 1\n"))
+
+	// the removed hashes leave the report without an alert
+	pba.state.db.Delete(prefixFileKey(missing))
+	pba.Commit()
+	pba.monitorRound()
+	assert.Equal(t, 1, len(alerts))
+	res = utils.queryMonitor(t, pba, ownerEdKey, nil)
+	json.Unmarshal(res.Value, &report)
+	assert.Equal(t, []UnavailableHash{}, report.Unavailable)
+
+	// the owner unsubscribes with an empty webhook
+	alertsDr = utils.createDelivery(t, ownerEdKey, DeliveryData{Action: ALERTS_ACTION, Webhook: utils.str("")})
+	b, _ = json.Marshal(alertsDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	assert.Equal(t, "", pba.getWebhook(ownerAddr))
+
+	pba = NewPBApplication()
+	assert.Equal(t, CodeTypeUnauthorized, utils.queryMonitor(t, pba, ownerEdKey, nil).Code)
+}
+
+func TestSpbMonitorReportOnlyForTheOwner(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	pba.monitor = newMonitor(time.Second)
+	utils := forTestUtils{}
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	otherEdKey := crypto.GenPrivKeyEd25519()
+	ownerAddr := ownerEdKey.PubKey().Address().String()
+
+	missing := "QmMissing"
+	pba.state.db.Set(prefixFileKey(missing), []byte(ownerAddr))
+	pba.Commit()
+	pba.monitorRound()
+
+	// the unsigned query does not get a report
+	assert.Equal(t, CodeTypeEncodingError, pba.Query(types.RequestQuery{Path: "/monitor"}).Code)
+	assert.Equal(t, CodeTypeEncodingError, pba.Query(types.RequestQuery{Path: "/monitor", Data: []byte(ownerAddr)}).Code)
+
+	// the other key sees only its own hashes and it can not ask for the owner
+	report := MonitorReport{}
+	res := utils.queryMonitor(t, pba, otherEdKey, nil)
+	assert.Equal(t, CodeTypeOK, res.Code)
+	json.Unmarshal(res.Value, &report)
+	assert.Equal(t, []UnavailableHash{}, report.Unavailable)
+	assert.Equal(t, CodeTypeUnauthorized, utils.queryMonitor(t, pba, otherEdKey, &ownerAddr).Code)
+
+	res = utils.queryMonitor(t, pba, ownerEdKey, nil)
+	json.Unmarshal(res.Value, &report)
+	assert.Equal(t, []UnavailableHash{{File: missing, Owner: ownerAddr, Since: report.Unavailable[0].Since}}, report.Unavailable)
+}
+
+func TestSpbAlertsNeedAPublicHttpsWebhook(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	ownerEdKey := crypto.GenPrivKeyEd25519()
+	alerts := func(webhook string) DeliveryRequest {
+		return utils.createDelivery(t, ownerEdKey, DeliveryData{Action: ALERTS_ACTION, Webhook: utils.str(webhook)})
+	}
+
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"ftp", alerts("ftp://example.com"), CodeTypeEncodingError},
+		{"http", alerts("http://example.com/alerts"), CodeTypeEncodingError},
+		{"localhost", alerts("https://localhost/alerts"), CodeTypeEncodingError},
+		{"loopback", alerts("https://127.0.0.1/alerts"), CodeTypeEncodingError},
+		{"loopback v6", alerts("https://[::1]/alerts"), CodeTypeEncodingError},
+		{"private", alerts("https://10.1.2.3/alerts"), CodeTypeEncodingError},
+		{"private 192.168", alerts("https://192.168.1.1:8443/alerts"), CodeTypeEncodingError},
+		{"link-local", alerts("https://169.254.169.254/latest"), CodeTypeEncodingError},
+		{"link-local v6", alerts("https://[fe80::1]/alerts"), CodeTypeEncodingError},
+		{"unspecified", alerts("https://0.0.0.0/alerts"), CodeTypeEncodingError},
+		{"public ip", alerts("https://8.8.8.8/alerts"), CodeTypeOK},
+		{"public name", alerts("https://example.com/alerts"), CodeTypeOK},
+	})
+}
+
+func TestSendAlertRefusesThePrivateAddresses(t *testing.T) {
+	// a public name can resolve to a private address, the alert is not sent to it
+	called := false
+	webhook := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer webhook.Close()
+
+	err := sendAlert(webhook.URL, &Alert{Owner: "owner"})
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "private address"))
+	assert.False(t, called)
+}
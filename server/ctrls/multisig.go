@@ -0,0 +1,86 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/tendermint/go-crypto"
+)
+
+func (pba *PBApplication) getMultisig(addr string) *MultisigDefinition {
+	b := pba.state.db.Get(prefixMultisigKey(addr))
+	if len(b) == 0 {
+		return nil
+	}
+	md := MultisigDefinition{}
+	json.Unmarshal(b, &md)
+	return &md
+}
+
+// isMember checks if the address belongs to one of the public keys of the multisig.
+func (md *MultisigDefinition) isMember(addr string) bool {
+	for _, v := range md.PubKeys {
+		pubk, err := crypto.PubKeyFromBytes(v)
+		if err == nil && pubk.Address().String() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// multisigValidation checks that the delivery for the multisig address
+// has the signatures of at least Threshold of its public keys.
+func (pba *PBApplication) multisigValidation(dr DeliveryRequest) (uint32, error) {
+	md := pba.getMultisig(*dr.Data.FromMultisig)
+	if md == nil {
+		return CodeTypeUnauthorized, errors.New("The multisig address does not exists.")
+	}
+	from, _ := crypto.PubKeyFromBytes(dr.Data.From)
+	if !md.isMember(from.Address().String()) {
+		return CodeTypeUnauthorized, errors.New("The public key is not a member of the multisig.")
+	}
+	signed := 0
+	for addr := range dr.SignerAddresses() {
+		if md.isMember(addr) {
+			signed++
+		}
+	}
+	if signed < md.Threshold {
+		return CodeTypeUnauthorized, errors.New("The multisig needs more signatures.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) multisigCreateActionValidation(dr DeliveryRequest) (uint32, error) {
+	md := dr.Data.Multisig
+	if md == nil {
+		return CodeTypeUnauthorized, errors.New("The multisig definition does not exists.")
+	}
+	if md.Threshold < 1 || md.Threshold > len(md.PubKeys) {
+		return CodeTypeUnauthorized, errors.New("The threshold needs to be between one and the number of public keys.")
+	}
+	members := map[string]bool{}
+	for _, v := range md.PubKeys {
+		pubk, err := crypto.PubKeyFromBytes(v)
+		if err != nil {
+			return CodeTypeEncodingError, errors.New("A public key of the multisig is not correct.")
+		}
+		members[pubk.Address().String()] = true
+	}
+	if len(members) != len(md.PubKeys) {
+		return CodeTypeUnauthorized, errors.New("The public keys of the multisig need to be different.")
+	}
+	from, _ := crypto.PubKeyFromBytes(dr.Data.From)
+	if !members[from.Address().String()] {
+		return CodeTypeUnauthorized, errors.New("The public key is not a member of the multisig.")
+	}
+	if pba.state.db.Has(prefixMultisigKey(md.Address())) {
+		return CodeTypeUnauthorized, errors.New("The multisig " + md.Address() + " already exists.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) multisigCreateActionState(dr DeliveryRequest) {
+	b, _ := json.Marshal(dr.Data.Multisig)
+	pba.state.db.Set(prefixMultisigKey(dr.Data.Multisig.Address()), b)
+}
@@ -0,0 +1,102 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/go-crypto"
+)
+
+func (f forTestUtils) createMultisig(t *testing.T, pba *PBApplication, threshold int,
+	members []crypto.PrivKeyEd25519) string {
+	md := MultisigDefinition{Threshold: threshold}
+	for _, v := range members {
+		md.PubKeys = append(md.PubKeys, v.PubKey().Bytes())
+	}
+	dd := DeliveryData{}
+	dd.Action = MULTISIG_CREATE_ACTION
+	dd.From = members[0].PubKey().Bytes()
+	dd.Multisig = &md
+	b, _ := json.Marshal(dd)
+	dr := DeliveryRequest{}
+	dr.Signature = members[0].Sign(b).Bytes()
+	dr.Data = dd
+	b, _ = json.Marshal(dr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	return md.Address()
+}
+
+func TestSpbMultisigReceiveAndRemoveSuccessfully(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	members := []crypto.PrivKeyEd25519{
+		crypto.GenPrivKeyEd25519(),
+		crypto.GenPrivKeyEd25519(),
+		crypto.GenPrivKeyEd25519(),
+	}
+	multisigAddr := utils.createMultisig(t, pba, 2, members)
+
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr := DeliveryData{}
+	sendDr.Action = SEND_ACTION
+	sendDr.From = fromEdKey.PubKey().Bytes()
+	sendDr.ToMultisig = &multisigAddr
+	sendDr.Files = addDr.Data.Files
+	b, _ = json.Marshal(sendDr)
+	dr := DeliveryRequest{Signature: fromEdKey.Sign(b).Bytes(), Data: sendDr}
+	b, _ = json.Marshal(dr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createDelivery(t, members[0], DeliveryData{
+		Action:       REMOVE_ACTION,
+		FromMultisig: &multisigAddr,
+		Files:        addDr.Data.Files,
+	})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	remDr = utils.createDelivery(t, members[1], DeliveryData{
+		Action:       REMOVE_ACTION,
+		FromMultisig: &multisigAddr,
+		Files:        addDr.Data.Files,
+	}, members[2:]...)
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbMultisigFailOnSignaturesFromOtherKeys(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	members := []crypto.PrivKeyEd25519{
+		crypto.GenPrivKeyEd25519(),
+		crypto.GenPrivKeyEd25519(),
+	}
+	multisigAddr := utils.createMultisig(t, pba, 2, members)
+
+	// only the hash of the file is needed, the delivery is created for the multisig
+	files := utils.createAddOrRemoveDelivery(t, members[0], ADD_ACTION, [][]byte{[]byte("random1")}).Data.Files
+	addDr := utils.createDelivery(t, members[0], DeliveryData{
+		Action:       ADD_ACTION,
+		FromMultisig: &multisigAddr,
+		Files:        files,
+	}, members[1:]...)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	outsider := crypto.GenPrivKeyEd25519()
+	remDr := utils.createDelivery(t, members[0], DeliveryData{
+		Action:       REMOVE_ACTION,
+		FromMultisig: &multisigAddr,
+		Files:        addDr.Data.Files,
+	}, outsider)
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
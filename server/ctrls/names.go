@@ -0,0 +1,78 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+
+	"github.com/tendermint/go-crypto"
+)
+
+// the names are lowercase, so they can be typed after the '@'
+var validName = regexp.MustCompile(`^[a-z0-9_-]{3,32}$`)
+
+func (pba *PBApplication) getName(name string) *NameRecord {
+	b := pba.state.db.Get(prefixNameKey(name))
+	if len(b) == 0 {
+		return nil
+	}
+	nr := NameRecord{}
+	json.Unmarshal(b, &nr)
+	return &nr
+}
+
+func (pba *PBApplication) setName(name string, pubKey []byte) {
+	b, _ := json.Marshal(NameRecord{Name: name, PubKey: pubKey})
+	pba.state.db.Set(prefixNameKey(name), b)
+}
+
+// nameValidation checks that the From signs with its own key, because the names resolve to public keys.
+func (pba *PBApplication) nameValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.FromMultisig != nil || dr.Data.FromAccount != nil {
+		return CodeTypeUnauthorized, errors.New("The name needs to be signed by the key of its owner.")
+	}
+	if dr.Data.Name == nil {
+		return CodeTypeUnauthorized, errors.New("The name does not exists.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) registerNameActionValidation(dr DeliveryRequest) (uint32, error) {
+	code, err := pba.nameValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	name := *dr.Data.Name
+	if !validName.MatchString(name) {
+		return CodeTypeUnauthorized,
+			errors.New("The name needs 3 to 32 lowercase letters, digits, '-' or '_'.")
+	}
+	if pba.state.db.Has(prefixNameKey(name)) {
+		return CodeTypeUnauthorized, errors.New("The name " + name + " already exists.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) transferNameActionValidation(dr DeliveryRequest) (uint32, error) {
+	code, err := pba.nameValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	name := *dr.Data.Name
+	nr := pba.getName(name)
+	if nr == nil {
+		return CodeTypeUnauthorized, errors.New("The name " + name + " does not exists.")
+	}
+	from, _ := crypto.PubKeyFromBytes(dr.Data.From)
+	owner, err := crypto.PubKeyFromBytes(nr.PubKey)
+	if err != nil || owner.Address().String() != from.Address().String() {
+		return CodeTypeUnauthorized, errors.New("The name " + name + " is not owned by you.")
+	}
+	if dr.Data.To == nil {
+		return CodeTypeUnauthorized, errors.New("The public key of the receiver does not exists.")
+	}
+	if _, err := crypto.PubKeyFromBytes(*dr.Data.To); err != nil {
+		return CodeTypeEncodingError, errors.New("The public key of the receiver is not correct.")
+	}
+	return CodeTypeOK, nil
+}
@@ -0,0 +1,55 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbRegisterAndResolveName(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	aliceEdKey := crypto.GenPrivKeyEd25519()
+	bobEdKey := crypto.GenPrivKeyEd25519()
+
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"the name is not lowercase", utils.createDelivery(t, aliceEdKey, DeliveryData{
+			Action: REGISTER_NAME_ACTION, Name: utils.str("Alice!")}), CodeTypeUnauthorized},
+		{"register the name", utils.createDelivery(t, aliceEdKey, DeliveryData{
+			Action: REGISTER_NAME_ACTION, Name: utils.str("alice")}), CodeTypeOK},
+		{"the name is taken", utils.createDelivery(t, bobEdKey, DeliveryData{
+			Action: REGISTER_NAME_ACTION, Name: utils.str("alice")}), CodeTypeUnauthorized},
+	})
+
+	b, _ := json.Marshal(NameRecord{Name: "alice", PubKey: aliceEdKey.PubKey().Bytes()})
+	assert.Equal(t, types.ResponseQuery{Code: CodeTypeOK, Value: b},
+		pba.Query(types.RequestQuery{Path: "/resolve", Data: []byte("alice")}))
+	assert.Equal(t, CodeTypeUnauthorized,
+		pba.Query(types.RequestQuery{Path: "/resolve", Data: []byte("bob")}).Code)
+}
+
+func TestSpbTransferName(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	aliceEdKey := crypto.GenPrivKeyEd25519()
+	bobEdKey := crypto.GenPrivKeyEd25519()
+
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"register the name", utils.createDelivery(t, aliceEdKey, DeliveryData{
+			Action: REGISTER_NAME_ACTION, Name: utils.str("alice")}), CodeTypeOK},
+		{"only the owner can transfer the name", utils.createDelivery(t, bobEdKey, DeliveryData{
+			Action: TRANSFER_NAME_ACTION, To: utils.pubKey(bobEdKey), Name: utils.str("alice")}), CodeTypeUnauthorized},
+		{"transfer the name", utils.createDelivery(t, aliceEdKey, DeliveryData{
+			Action: TRANSFER_NAME_ACTION, To: utils.pubKey(bobEdKey), Name: utils.str("alice")}), CodeTypeOK},
+	})
+
+	b, _ := json.Marshal(NameRecord{Name: "alice", PubKey: bobEdKey.PubKey().Bytes()})
+	assert.Equal(t, types.ResponseQuery{Code: CodeTypeOK, Value: b},
+		pba.Query(types.RequestQuery{Path: "/resolve", Data: []byte("alice")}))
+}
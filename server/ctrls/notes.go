@@ -0,0 +1,138 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/ipfs/go-ipfs-api"
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+)
+
+// Note is the json of a file on a chain with notes, like {"value":5,"currency":"EUR"}.
+type Note struct {
+	Value    json.Number `json:"value"`
+	Currency string      `json:"currency,omitempty"`
+}
+
+// readNote reads the note of the hash from the IPFS.
+func readNote(hash string) (*Note, int64, error) {
+	sh := shell.NewShell(conf.Conf.IpfsConnection)
+	b, err := sh.BlockGet(hash)
+	if err != nil {
+		return nil, 0, errors.New("The file " + hash + " does not exists.")
+	}
+	n := Note{}
+	err = json.Unmarshal(b, &n)
+	if err != nil {
+		return nil, 0, errors.New("The hash " + hash + " is not a json note.")
+	}
+	value, err := n.Value.Int64()
+	if err != nil || value <= 0 {
+		return nil, 0, errors.New("The value of the note " + hash + " needs to be a positive integer.")
+	}
+	return &n, value, nil
+}
+
+// notesValue returns the sum of the values of the notes, they need to have the same currency.
+func notesValue(hashes []string, currency *string) (int64, error) {
+	sum := int64(0)
+	for _, v := range hashes {
+		n, value, err := readNote(v)
+		if err != nil {
+			return 0, err
+		}
+		if currency == nil {
+			currency = &n.Currency
+		}
+		if n.Currency != *currency {
+			return 0, errors.New("The note " + v + " is not in the currency " + *currency + ".")
+		}
+		// the sum can not wrap, or the new notes could have more value than the notes
+		if sum > math.MaxInt64-value {
+			return 0, errors.New("The values of the notes are too big.")
+		}
+		sum += value
+	}
+	return sum, nil
+}
+
+func hasDuplicates(hashes []string) bool {
+	seen := map[string]bool{}
+	for _, v := range hashes {
+		if seen[v] {
+			return true
+		}
+		seen[v] = true
+	}
+	return false
+}
+
+func (pba *PBApplication) noteActionValidation(dr DeliveryRequest) (uint32, error) {
+	if !conf.Conf.Notes {
+		return CodeTypeUnauthorized, errors.New("The chain does not have notes.")
+	}
+	if conf.Conf.Blockchain == conf.OtoOPB {
+		return CodeTypeUnauthorized,
+			errors.New("For one to one blockchain, the notes can not be split or merged because a key has one file.")
+	}
+	if dr.Data.Action == SPLIT_ACTION && (len(dr.Data.Files) != 1 || len(dr.Data.NewFiles) < 2) {
+		return CodeTypeUnauthorized, errors.New("The split needs one note and at least two new notes.")
+	}
+	if dr.Data.Action == MERGE_ACTION && (len(dr.Data.Files) < 2 || len(dr.Data.NewFiles) != 1) {
+		return CodeTypeUnauthorized, errors.New("The merge needs at least two notes and one new note.")
+	}
+	if hasDuplicates(append(append([]string{}, dr.Data.Files...), dr.Data.NewFiles...)) {
+		return CodeTypeUnauthorized, errors.New("The notes and the new notes need to be different.")
+	}
+
+	// the From burns the notes, so it needs to be able to remove them
+	code, err := pba.removeActionValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	newDr := dr
+	newDr.Data.Files = dr.Data.NewFiles
+	code, err = pba.addActionValidation(newDr)
+	if err != nil {
+		return code, err
+	}
+
+	issuer := pba.getIssuer(dr.Data.Files[0])
+	for _, v := range dr.Data.Files {
+		if pba.getIssuer(v) != issuer {
+			return CodeTypeUnauthorized, errors.New("The notes need to have the same issuer.")
+		}
+	}
+
+	value, err := notesValue(dr.Data.Files, nil)
+	if err != nil {
+		return CodeTypeEncodingError, err
+	}
+	n, _, _ := readNote(dr.Data.Files[0])
+	newValue, err := notesValue(dr.Data.NewFiles, &n.Currency)
+	if err != nil {
+		return CodeTypeEncodingError, err
+	}
+	if value != newValue {
+		return CodeTypeUnauthorized, errors.New("The new notes have the value " + strconv.FormatInt(newValue, 10) +
+			" but the notes have the value " + strconv.FormatInt(value, 10) + ".")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) noteActionState(dr DeliveryRequest) {
+	// the new notes keep the issuer of the notes
+	issuer := pba.getIssuer(dr.Data.Files[0])
+	pba.removeActionState(dr)
+	for _, v := range dr.Data.Files {
+		pba.state.db.Set(prefixBurnedKey(v), []byte{1})
+	}
+	newDr := dr
+	newDr.Data.Files = dr.Data.NewFiles
+	pba.addActionState(newDr)
+	for _, v := range dr.Data.NewFiles {
+		pba.state.db.Set(prefixIssuerKey(v), []byte(issuer))
+	}
+}
@@ -0,0 +1,184 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ipfs/go-ipfs-api"
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/go-crypto"
+)
+
+func (f forTestUtils) createNotes(t *testing.T, notes []string) []string {
+	sh := shell.NewShell(conf.Conf.IpfsConnection)
+	hashes := []string{}
+	for _, v := range notes {
+		hash, err := sh.BlockPut([]byte(v))
+		assert.Nil(t, err)
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+func TestSpbSplitAndMergeNotes(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	conf.Conf.Notes = true
+	defer func() { conf.Conf.Notes = false }()
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	edKey := crypto.GenPrivKeyEd25519()
+
+	notes := utils.createNotes(t, []string{
+		`{"serial":"a1","value":5,"currency":"EUR"}`,
+		`{"serial":"a2","value":2,"currency":"EUR"}`,
+		`{"serial":"a3","value":3,"currency":"EUR"}`,
+		`{"serial":"a4","value":5,"currency":"EUR"}`,
+	})
+	utils.deliver(t, pba, utils.createDelivery(t, edKey, DeliveryData{
+		Action: ADD_ACTION,
+		Files:  notes[:1],
+	}), CodeTypeOK)
+
+	// the new notes need to have the same value
+	splitDr := utils.createDelivery(t, edKey, DeliveryData{
+		Action:   SPLIT_ACTION,
+		Files:    notes[:1],
+		NewFiles: notes[1:2],
+	})
+	b, _ := json.Marshal(splitDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	splitDr = utils.createDelivery(t, edKey, DeliveryData{
+		Action:   SPLIT_ACTION,
+		Files:    notes[:1],
+		NewFiles: []string{notes[1], notes[1]},
+	})
+	b, _ = json.Marshal(splitDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	splitDr = utils.createDelivery(t, edKey, DeliveryData{Action: SPLIT_ACTION, Files: notes[:1], NewFiles: notes[1:3]})
+	b, _ = json.Marshal(splitDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the split note is burned
+	addDr := utils.createDelivery(t, edKey, DeliveryData{Action: ADD_ACTION, Files: notes[:1]})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	mergeDr := utils.createDelivery(t, edKey, DeliveryData{
+		Action:   MERGE_ACTION,
+		Files:    notes[1:3],
+		NewFiles: notes[3:],
+	})
+	b, _ = json.Marshal(mergeDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createDelivery(t, edKey, DeliveryData{Action: REMOVE_ACTION, Files: notes[3:]})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbSplitFailOnOtherCurrencyOrNotOwnedNote(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	conf.Conf.Notes = true
+	defer func() { conf.Conf.Notes = false }()
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	edKey := crypto.GenPrivKeyEd25519()
+	otherEdKey := crypto.GenPrivKeyEd25519()
+
+	notes := utils.createNotes(t, []string{
+		`{"serial":"b1","value":5,"currency":"EUR"}`,
+		`{"serial":"b2","value":2,"currency":"USD"}`,
+		`{"serial":"b3","value":3,"currency":"EUR"}`,
+	})
+	utils.deliver(t, pba, utils.createDelivery(t, edKey, DeliveryData{
+		Action: ADD_ACTION,
+		Files:  notes[:1],
+	}), CodeTypeOK)
+
+	splitDr := utils.createDelivery(t, edKey, DeliveryData{Action: SPLIT_ACTION, Files: notes[:1], NewFiles: notes[1:]})
+	b, _ := json.Marshal(splitDr)
+	assert.Equal(t, CodeTypeEncodingError, pba.DeliverTx(b).Code)
+
+	splitDr = utils.createDelivery(t, otherEdKey, DeliveryData{
+		Action:   SPLIT_ACTION,
+		Files:    notes[:1],
+		NewFiles: notes[1:],
+	})
+	b, _ = json.Marshal(splitDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestSpbSplitFailWithoutNotes(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	edKey := crypto.GenPrivKeyEd25519()
+
+	notes := utils.createNotes(t, []string{
+		`{"serial":"c1","value":5}`,
+		`{"serial":"c2","value":2}`,
+		`{"serial":"c3","value":3}`,
+	})
+	utils.deliver(t, pba, utils.createDelivery(t, edKey, DeliveryData{
+		Action: ADD_ACTION,
+		Files:  notes[:1],
+	}), CodeTypeOK)
+
+	splitDr := utils.createDelivery(t, edKey, DeliveryData{Action: SPLIT_ACTION, Files: notes[:1], NewFiles: notes[1:]})
+	b, _ := json.Marshal(splitDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestSpbSplitFailWhenTheValuesOverflow(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	conf.Conf.Notes = true
+	defer func() { conf.Conf.Notes = false }()
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	edKey := crypto.GenPrivKeyEd25519()
+
+	notes := utils.createNotes(t, []string{
+		`{"serial":"d1","value":1,"currency":"EUR"}`,
+		`{"serial":"d2","value":9223372036854775807,"currency":"EUR"}`,
+		`{"serial":"d3","value":9223372036854775807,"currency":"EUR"}`,
+		`{"serial":"d4","value":3,"currency":"EUR"}`,
+		`{"serial":"d5","value":2,"currency":"EUR"}`,
+		`{"serial":"d6","value":-1,"currency":"EUR"}`,
+		`{"serial":"d7","value":0,"currency":"EUR"}`,
+	})
+	utils.deliver(t, pba, utils.createDelivery(t, edKey, DeliveryData{
+		Action: ADD_ACTION,
+		Files:  notes[:1],
+	}), CodeTypeOK)
+
+	// the sum of 2^63-1, 2^63-1 and 3 wraps to 1 on int64
+	splitDr := utils.createDelivery(t, edKey, DeliveryData{
+		Action:   SPLIT_ACTION,
+		Files:    notes[:1],
+		NewFiles: notes[1:4],
+	})
+	b, _ := json.Marshal(splitDr)
+	res := pba.DeliverTx(b)
+	assert.Equal(t, CodeTypeEncodingError, res.Code)
+	assert.Equal(t, "The values of the notes are too big.", res.Log)
+
+	// the parts can not be negative or zero
+	splitDr = utils.createDelivery(t, edKey, DeliveryData{
+		Action:   SPLIT_ACTION,
+		Files:    notes[:1],
+		NewFiles: []string{notes[4], notes[5]},
+	})
+	b, _ = json.Marshal(splitDr)
+	assert.Equal(t, CodeTypeEncodingError, pba.DeliverTx(b).Code)
+
+	splitDr = utils.createDelivery(t, edKey, DeliveryData{
+		Action:   SPLIT_ACTION,
+		Files:    notes[:1],
+		NewFiles: []string{notes[4], notes[6]},
+	})
+	b, _ = json.Marshal(splitDr)
+	assert.Equal(t, CodeTypeEncodingError, pba.DeliverTx(b).Code)
+}
@@ -2,6 +2,8 @@ package ctrls
 
 import (
 	"encoding/binary"
+	"encoding/json"
+	"strconv"
 
 	"github.com/tendermint/abci/types"
 )
@@ -13,5 +15,61 @@ func (pba *PBApplication) Commit() types.ResponseCommit {
 	pba.state.AppHash = appHash
 	pba.state.Height += 1
 	saveState(pba.state)
+	pba.saveCommitted()
+	pba.queuePins()
 	return types.ResponseCommit{Data: appHash}
 }
+
+// GenesisState is the app_state of the genesis.
+type GenesisState struct {
+	Admins         []string `json:"admins,omitempty"`          // the addresses that can sign the admin actions
+	AdminThreshold int      `json:"admin_threshold,omitempty"` // the admins that need to sign an admin action, one by default
+	Issuers        []string `json:"issuers,omitempty"`         // the addresses that can add hashes
+}
+
+// InitChain sets the state of the genesis, a wrong genesis stops the node
+// because the validators would not agree on the state.
+func (pba *PBApplication) InitChain(req types.RequestInitChain) types.ResponseInitChain {
+	gs := GenesisState{}
+	if len(req.AppStateBytes) > 0 {
+		if err := json.Unmarshal(req.AppStateBytes, &gs); err != nil {
+			panic("The app_state of the genesis is not correct: " + err.Error())
+		}
+	}
+	admins, err := upperAddresses("admin", gs.Admins)
+	if err != nil {
+		panic("The app_state of the genesis is not correct: " + err.Error())
+	}
+	if gs.AdminThreshold < 0 || gs.AdminThreshold > len(admins) {
+		panic("The app_state of the genesis is not correct: the admin threshold " +
+			strconv.Itoa(gs.AdminThreshold) + " is not between 1 and the number of the admins.")
+	}
+	threshold := gs.AdminThreshold
+	if threshold == 0 {
+		threshold = 1
+	}
+	issuers, err := upperAddresses("issuer", gs.Issuers)
+	if err != nil {
+		panic("The app_state of the genesis is not correct: " + err.Error())
+	}
+	pba.setAdminSet(AdminSet{Addresses: admins, Threshold: threshold})
+	pba.setIssuers(issuers)
+	return types.ResponseInitChain{}
+}
+
+func (pba *PBApplication) BeginBlock(req types.RequestBeginBlock) types.ResponseBeginBlock {
+	pba.state.Time = req.Header.Time
+	return types.ResponseBeginBlock{}
+}
+
+func (pba *PBApplication) EndBlock(req types.RequestEndBlock) types.ResponseEndBlock {
+	pba.expireHashLocks(req.Height)
+	pba.executeRecoveries(req.Height)
+	pba.returnLeases(req.Height)
+	return types.ResponseEndBlock{}
+}
+
+// currentHeight is the height of the block that the transactions are delivered.
+func (pba *PBApplication) currentHeight() int64 {
+	return pba.state.Height + 1
+}
@@ -0,0 +1,268 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ipfs/go-ipfs-api"
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+)
+
+// pinTimeout is the time that the IPFS has for a pin, so a missing hash does not block the pinner.
+const pinTimeout = 2 * time.Minute
+
+// PinReport is what the reconciliation changed in the local pinset.
+type PinReport struct {
+	Pinned   []string
+	Unpinned []string
+	Failed   []string `json:",omitempty"` // the hashes that the IPFS could not pin or unpin
+}
+
+type pinJob struct {
+	pins      []string
+	unpins    []string
+	reconcile func() []string   // the hashes of the chain, only for a reconciliation
+	done      chan<- *PinReport // it is closed when the job finishes
+}
+
+// Pinner pins the hashes of the chain in the local IPFS after each commit.
+// It runs outside the consensus, so a slow or failed pin never changes the state,
+// the reconciliation repairs the drift later.
+type Pinner struct {
+	jobs chan pinJob
+	// the hashes that the chain had since the start, only them are unpinned,
+	// so the other pins of the IPFS are not touched
+	managed map[string]bool
+	// the file that keeps the managed hashes after a restart, only in memory when it is empty
+	managedFile string
+	// the managed hashes changed since the last save
+	changed bool
+}
+
+func newPinner(managedFile string) (*Pinner, error) {
+	p := &Pinner{jobs: make(chan pinJob, 1024), managed: map[string]bool{}, managedFile: managedFile}
+	if len(managedFile) > 0 {
+		b, err := ioutil.ReadFile(managedFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.New("The file of the pinned hashes can not be read: " + err.Error())
+		}
+		hashes := []string{}
+		if len(b) > 0 {
+			if err := json.Unmarshal(b, &hashes); err != nil {
+				return nil, errors.New("The file of the pinned hashes is not a json list.")
+			}
+		}
+		for _, v := range hashes {
+			p.managed[v] = true
+		}
+	}
+	go p.run()
+	return p, nil
+}
+
+// saveManaged writes the managed hashes in the file, a failed write is repaired by the next one.
+func (p *Pinner) saveManaged() {
+	if len(p.managedFile) == 0 {
+		return
+	}
+	hashes := []string{}
+	for v := range p.managed {
+		hashes = append(hashes, v)
+	}
+	sort.Strings(hashes)
+	b, _ := json.Marshal(hashes)
+	// the rename replaces the file at once, so a crash does not leave half of it
+	tmp := p.managedFile + ".tmp"
+	err := ioutil.WriteFile(tmp, b, 0600)
+	if err == nil {
+		err = os.Rename(tmp, p.managedFile)
+	}
+	if err != nil {
+		log.Println("The pinned hashes could not be saved: " + err.Error())
+	}
+}
+
+func (p *Pinner) run() {
+	sh := shell.NewShell(conf.Conf.IpfsConnection)
+	sh.SetTimeout(pinTimeout)
+	for job := range p.jobs {
+		report := &PinReport{}
+		if job.reconcile != nil {
+			report = p.reconcile(sh, job.reconcile())
+		} else {
+			for _, v := range job.pins {
+				p.pin(sh, v, report)
+			}
+			for _, v := range job.unpins {
+				p.unpin(sh, v, report)
+			}
+		}
+		if p.changed {
+			p.saveManaged()
+			p.changed = false
+		}
+		if job.done != nil {
+			job.done <- report
+			close(job.done)
+		}
+	}
+}
+
+func (p *Pinner) pin(sh *shell.Shell, hash string, report *PinReport) {
+	p.manage(hash)
+	if err := sh.Pin(hash); err != nil {
+		log.Println("The pin of the hash " + hash + " failed: " + err.Error())
+		report.Failed = append(report.Failed, hash)
+		return
+	}
+	report.Pinned = append(report.Pinned, hash)
+}
+
+// unpin removes only the pins that the pinner made, the other pins of the IPFS are not its own.
+// A failed unpin stays managed, so the reconciliation tries it again.
+func (p *Pinner) unpin(sh *shell.Shell, hash string, report *PinReport) {
+	if !p.managed[hash] {
+		return
+	}
+	if err := sh.Unpin(hash); err != nil {
+		log.Println("The unpin of the hash " + hash + " failed: " + err.Error())
+		report.Failed = append(report.Failed, hash)
+		return
+	}
+	delete(p.managed, hash)
+	p.changed = true
+	report.Unpinned = append(report.Unpinned, hash)
+}
+
+// manage marks the hash as pinned by the pinner.
+func (p *Pinner) manage(hash string) {
+	if !p.managed[hash] {
+		p.managed[hash] = true
+		p.changed = true
+	}
+}
+
+// reconcile pins the hashes of the chain that are not pinned,
+// and unpins the managed hashes that the chain does not have anymore.
+func (p *Pinner) reconcile(sh *shell.Shell, hashes []string) *PinReport {
+	report := &PinReport{}
+	pins, err := sh.Pins()
+	if err != nil {
+		log.Println("The pins of the IPFS could not be listed: " + err.Error())
+		report.Failed = hashes
+		return report
+	}
+	onChain := map[string]bool{}
+	for _, v := range hashes {
+		onChain[v] = true
+		if info, ok := pins[v]; !ok || info.Type != shell.RecursivePin {
+			p.pin(sh, v, report)
+		}
+		p.manage(v)
+	}
+	for v := range p.managed {
+		if info, ok := pins[v]; ok && info.Type == shell.RecursivePin && !onChain[v] {
+			p.unpin(sh, v, report)
+		}
+	}
+	return report
+}
+
+// EnablePinning starts the pinner, the hashes of the next commits are pinned.
+// The managedFile keeps the hashes that the pinner pinned, so a restart can unpin them.
+func (pba *PBApplication) EnablePinning(managedFile string) error {
+	p, err := newPinner(managedFile)
+	if err != nil {
+		return err
+	}
+	pba.pinner = p
+	pba.saveCommitted()
+	return nil
+}
+
+// touchFiles keeps the hashes that the delivery changed, the commit pins or unpins them.
+func (pba *PBApplication) touchFiles(dr DeliveryRequest) {
+	if pba.pinner == nil {
+		return
+	}
+	pba.touched = append(pba.touched, dr.Data.Files...)
+	pba.touched = append(pba.touched, dr.Data.ToFiles...)
+	pba.touched = append(pba.touched, dr.Data.NewFiles...)
+	for _, v := range dr.recipientSends() {
+		pba.touched = append(pba.touched, v.Data.Files...)
+	}
+}
+
+// needsPin is true for the hashes that the chain needs, the owned hashes and the registered schemas.
+func (pba *PBApplication) needsPin(hash string) bool {
+	return pba.state.db.Has(prefixFileKey(hash)) || pba.state.db.Has(prefixSchemaKey(hash))
+}
+
+// pinHashes returns all the hashes that the chain needed in the IPFS at the last commit.
+func (pba *PBApplication) pinHashes() []string {
+	cs := pba.lastCommitted()
+	hashes := []string{}
+	for v := range cs.owners {
+		hashes = append(hashes, v)
+	}
+	sort.Strings(hashes)
+	return append(hashes, cs.schemas...)
+}
+
+// queuePins sends the hashes of the committed block to the pinner, after the state is saved.
+func (pba *PBApplication) queuePins() {
+	if pba.pinner == nil || len(pba.touched) == 0 {
+		return
+	}
+	job := pinJob{}
+	seen := map[string]bool{}
+	for _, v := range pba.touched {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		if pba.needsPin(v) {
+			job.pins = append(job.pins, v)
+		} else {
+			job.unpins = append(job.unpins, v)
+		}
+	}
+	pba.touched = nil
+	// the commit does not wait for the pinner, when it is behind the reconciliation repairs the drift
+	select {
+	case pba.pinner.jobs <- job:
+	default:
+		log.Println("The pinner is behind, the hashes of the block at the height " +
+			strconv.FormatInt(pba.state.Height, 10) + " were not pinned.")
+	}
+}
+
+// ReconcilePins repairs the drift between the hashes of the chain and the local pinset.
+// It runs outside the consensus on the last commit, so a long reconciliation does not block the blocks.
+func (pba *PBApplication) ReconcilePins() *PinReport {
+	done := make(chan *PinReport, 1)
+	pba.pinner.jobs <- pinJob{reconcile: pba.pinHashes, done: done}
+	return <-done
+}
+
+// ServeReconcile runs the reconciliation for the operator of the node on a POST,
+// it is served only on the local address of the -pins-admin.
+func (pba *PBApplication) ServeReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "The reconciliation needs a POST.", http.StatusMethodNotAllowed)
+		return
+	}
+	if pba.pinner == nil {
+		http.Error(w, "The pinning is not enabled.", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pba.ReconcilePins())
+}
@@ -0,0 +1,139 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-ipfs-api"
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+// waitPinner waits for the jobs of the previous commits.
+func (f forTestUtils) waitPinner(pba *PBApplication) {
+	done := make(chan *PinReport, 1)
+	pba.pinner.jobs <- pinJob{done: done}
+	<-done
+}
+
+func (f forTestUtils) isPinned(t *testing.T, hash string) bool {
+	sh := shell.NewShell(conf.Conf.IpfsConnection)
+	pins, err := sh.Pins()
+	assert.Nil(t, err)
+	_, ok := pins[hash]
+	return ok
+}
+
+func TestSpbPinTheHashesAfterTheCommit(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	assert.Nil(t, pba.EnablePinning(""))
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("pin1"), []byte("pin2")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the hashes are pinned only after the commit
+	utils.waitPinner(pba)
+	assert.False(t, utils.isPinned(t, addDr.Data.Files[0]))
+	pba.Commit()
+	utils.waitPinner(pba)
+	assert.True(t, utils.isPinned(t, addDr.Data.Files[0]))
+	assert.True(t, utils.isPinned(t, addDr.Data.Files[1]))
+
+	sendDr := utils.createSendDelivery(t, fromEdKey, &toEdKey, SEND_ACTION, addDr.Data.Files[:1])
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	remDr := utils.createAddOrRemoveDelivery(t, fromEdKey, REMOVE_ACTION, [][]byte{[]byte("pin2")})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	pba.Commit()
+	utils.waitPinner(pba)
+	assert.True(t, utils.isPinned(t, addDr.Data.Files[0]))
+	assert.False(t, utils.isPinned(t, addDr.Data.Files[1]))
+
+	// the reconciliation repairs the drift of the local pinset, the other pins stay,
+	// like the pin of the removed hash that the operator made after the pinner unpinned it
+	sh := shell.NewShell(conf.Conf.IpfsConnection)
+	other, _ := sh.BlockPut([]byte("not on the chain"))
+	assert.Nil(t, sh.Pin(other))
+	assert.Nil(t, sh.Unpin(addDr.Data.Files[0]))
+	assert.Nil(t, sh.Pin(addDr.Data.Files[1]))
+
+	// the block that is not committed is not reconciled
+	uncommittedDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("pin3")})
+	b, _ = json.Marshal(uncommittedDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	report := utils.reconcile(t, pba, http.StatusOK)
+	assert.Equal(t, PinReport{Pinned: addDr.Data.Files[:1]}, *report)
+	assert.True(t, utils.isPinned(t, addDr.Data.Files[0]))
+	assert.True(t, utils.isPinned(t, addDr.Data.Files[1]))
+	assert.False(t, utils.isPinned(t, uncommittedDr.Data.Files[0]))
+	assert.True(t, utils.isPinned(t, other))
+
+	// the reconciliation is a POST and it needs the pinner
+	rec := httptest.NewRecorder()
+	pba.ServeReconcile(rec, httptest.NewRequest("GET", "/pins/reconcile", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	utils.reconcile(t, NewPBApplication(), http.StatusNotFound)
+	// it is not a query of the chain
+	assert.NotEqual(t, CodeTypeOK, pba.Query(types.RequestQuery{Path: "/pins/reconcile"}).Code)
+}
+
+func (f forTestUtils) reconcile(t *testing.T, pba *PBApplication, status int) *PinReport {
+	rec := httptest.NewRecorder()
+	pba.ServeReconcile(rec, httptest.NewRequest("POST", "/pins/reconcile", nil))
+	assert.Equal(t, status, rec.Code)
+	report := PinReport{}
+	json.Unmarshal(rec.Body.Bytes(), &report)
+	return &report
+}
+
+func TestSpbPinnerKeepsItsHashesAfterARestart(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	dir, err := ioutil.TempDir("", "pins")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	managedFile := filepath.Join(dir, "pins.json")
+	pba := NewPBApplication()
+	assert.Nil(t, pba.EnablePinning(managedFile))
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("restart1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	pba.Commit()
+	utils.waitPinner(pba)
+	assert.True(t, utils.isPinned(t, addDr.Data.Files[0]))
+
+	// the new node does not have the hash on its chain, but it knows that it pinned it
+	pba = NewPBApplication()
+	assert.Nil(t, pba.EnablePinning(managedFile))
+	report := utils.reconcile(t, pba, http.StatusOK)
+	assert.Equal(t, PinReport{Unpinned: addDr.Data.Files}, *report)
+	assert.False(t, utils.isPinned(t, addDr.Data.Files[0]))
+
+	// the unpinned hash is not managed anymore, so a new pin of the operator stays
+	assert.Nil(t, shell.NewShell(conf.Conf.IpfsConnection).Pin(addDr.Data.Files[0]))
+	report = utils.reconcile(t, pba, http.StatusOK)
+	assert.Equal(t, PinReport{}, *report)
+	assert.True(t, utils.isPinned(t, addDr.Data.Files[0]))
+	b, err = ioutil.ReadFile(managedFile)
+	assert.Nil(t, err)
+	assert.Equal(t, "[]", string(b))
+
+	assert.Nil(t, ioutil.WriteFile(managedFile, []byte("not a json"), 0600))
+	assert.NotNil(t, NewPBApplication().EnablePinning(managedFile))
+}
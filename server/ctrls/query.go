@@ -33,7 +33,9 @@ func (pba *PBApplication) validateSpbQuery(sq SpbQuery) (uint32, error) {
 	fromAddr, _ := sq.FromPubKeyAddress()
 	if sq.Data.UserAddr != nil {
 		_, ok := conf.Conf.GetAuthorizedAddresses()[fromAddr]
-		if !ok {
+		// the members of a multisig can check its files
+		md := pba.getMultisig(*sq.Data.UserAddr)
+		if !ok && (md == nil || !md.isMember(fromAddr)) {
 			return CodeTypeUnauthorized, errors.New("You are not authorized to check other user's files.")
 		}
 	}
@@ -84,6 +86,20 @@ func (pba *PBApplication) spbQuery(sq SpbQuery) []byte {
 		}
 
 	}
+
+	userAddr := fromAddr
+	if sq.Data.UserAddr != nil {
+		userAddr = *sq.Data.UserAddr
+	}
+	for _, v := range pba.escrowedFiles(userAddr) {
+		if sq.Data.File == nil || *sq.Data.File == v {
+			qresp.InEscrow = append(qresp.InEscrow, v)
+		}
+	}
+	qresp.Vesting = pba.vestingFiles(qresp.Files)
+	qresp.Approvals = pba.approvals(userAddr)
+	qresp.Issuers = pba.fileIssuers(append(qresp.Files, qresp.InEscrow...))
+	qresp.Schemas = pba.fileSchemas(qresp.Files)
 	b, _ := json.Marshal(qresp)
 	return b
 }
@@ -98,12 +114,57 @@ func (pba *PBApplication) otopbQuery(oq OtopbQuery) []byte {
 	json.Unmarshal(filesBy, &files)
 
 	qresp.Files = files
+	qresp.InEscrow = pba.escrowedFiles(fromAddr)
+	qresp.Vesting = pba.vestingFiles(qresp.Files)
+	qresp.Approvals = pba.approvals(fromAddr)
+	qresp.Issuers = pba.fileIssuers(append(qresp.Files, qresp.InEscrow...))
+	qresp.Schemas = pba.fileSchemas(qresp.Files)
 	b, _ := json.Marshal(qresp)
 	return b
 
 }
 
 func (pba *PBApplication) Query(qreq types.RequestQuery) types.ResponseQuery {
+	// the audit log of the admin actions is public
+	if qreq.Path == "/audit" {
+		b, _ := json.Marshal(pba.auditLog())
+		return types.ResponseQuery{Code: CodeTypeOK, Value: b}
+	}
+	if qreq.Path == "/resolve" {
+		nr := pba.getName(string(qreq.Data))
+		if nr == nil {
+			return types.ResponseQuery{Code: CodeTypeUnauthorized, Log: "The name " + string(qreq.Data) + " does not exists."}
+		}
+		b, _ := json.Marshal(nr)
+		return types.ResponseQuery{Code: CodeTypeOK, Value: b}
+	}
+	if qreq.Path == "/issuers" {
+		b, _ := json.Marshal(pba.getIssuers())
+		return types.ResponseQuery{Code: CodeTypeOK, Value: b}
+	}
+	// the data is a signed query like the query of the SPB, the report has only the hashes of the From,
+	// or of the UserAddr for an admin or a member of its multisig
+	if qreq.Path == "/monitor" {
+		if pba.monitor == nil {
+			return types.ResponseQuery{Code: CodeTypeUnauthorized, Log: "The monitor is not enabled."}
+		}
+		sq := SpbQuery{}
+		json.Unmarshal(qreq.Data, &sq)
+		code, err := pba.validateSpbQuery(sq)
+		if err != nil {
+			return types.ResponseQuery{Code: code, Log: err.Error()}
+		}
+		owner, _ := sq.FromPubKeyAddress()
+		if sq.Data.UserAddr != nil {
+			owner = *sq.Data.UserAddr
+		}
+		b, _ := json.Marshal(pba.monitorReport(owner))
+		return types.ResponseQuery{Code: CodeTypeOK, Value: b}
+	}
+	if qreq.Path == "/schemas" {
+		b, _ := json.Marshal(pba.getSchemas())
+		return types.ResponseQuery{Code: CodeTypeOK, Value: b}
+	}
 
 	if conf.Conf.Blockchain == conf.SPB {
 		sq := SpbQuery{}
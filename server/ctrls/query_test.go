@@ -50,6 +50,7 @@ func TestSpbQuerySuccessfully(t *testing.T) {
 	// the value that we expect
 	qr := QueryResponse{}
 	qr.Files = addDr.Data.Files
+	qr.Issuers = []FileIssuer{{File: addDr.Data.Files[0], Issuer: fromEdKey.PubKey().Address().String()}}
 	b, _ = json.Marshal(qr)
 	res := types.ResponseQuery{Code: CodeTypeOK}
 	res.Value = b
@@ -110,6 +111,7 @@ func TestSpbQuerySuccessfullyGiveOneFile(t *testing.T) {
 	// the value that we expect
 	qr := QueryResponse{}
 	qr.Files = []string{addDr.Data.Files[0]}
+	qr.Issuers = []FileIssuer{{File: addDr.Data.Files[0], Issuer: fromEdKey.PubKey().Address().String()}}
 	b, _ = json.Marshal(qr)
 	res := types.ResponseQuery{Code: CodeTypeOK}
 	res.Value = b
@@ -149,7 +151,7 @@ func TestSpbQuerySuccessOnQueryOtherUsersFiles(t *testing.T) {
 	hash, err := sh.BlockPut(b)
 	assert.Nil(t, err)
 	conf.Conf.AuthorizedAddressesIpfsHash = hash
-	conf.Conf.SetAuthorizedAddresses()
+	assert.Nil(t, conf.Conf.SetAuthorizedAddresses())
 
 	fromEdKey := crypto.GenPrivKeyEd25519()
 	input := [][]byte{[]byte("random1")}
@@ -166,6 +168,7 @@ func TestSpbQuerySuccessOnQueryOtherUsersFiles(t *testing.T) {
 	// the value that we expect
 	qr := QueryResponse{}
 	qr.Files = addDr.Data.Files
+	qr.Issuers = []FileIssuer{{File: addDr.Data.Files[0], Issuer: fromEdKey.PubKey().Address().String()}}
 	b, _ = json.Marshal(qr)
 	res := types.ResponseQuery{Code: CodeTypeOK}
 	res.Value = b
@@ -215,6 +218,7 @@ func TestOtopbQuerySuccesfully(t *testing.T) {
 
 	qr := QueryResponse{}
 	qr.Files = addDr.Data.Files
+	qr.Issuers = []FileIssuer{{File: addDr.Data.Files[0], Issuer: fromEdKey.PubKey().Address().String()}}
 	b, _ = json.Marshal(qr)
 	res := types.ResponseQuery{Code: CodeTypeOK}
 	res.Value = b
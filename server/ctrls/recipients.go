@@ -0,0 +1,60 @@
+package ctrls
+
+import (
+	"errors"
+	"strconv"
+)
+
+// recipientSends returns a send for each recipient, they keep the Vesting of the send.
+func (dr *DeliveryRequest) recipientSends() []DeliveryRequest {
+	sends := []DeliveryRequest{}
+	for _, v := range dr.Data.Recipients {
+		data := dr.Data
+		to := v.To
+		data.To = &to
+		data.Files = v.Files
+		data.Recipients = nil
+		sends = append(sends, DeliveryRequest{Signature: dr.Signature, Data: data})
+	}
+	return sends
+}
+
+func (pba *PBApplication) recipientsValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.To != nil || dr.Data.ToMultisig != nil || len(dr.Data.Files) > 0 {
+		return CodeTypeUnauthorized, errors.New("The send with recipients can not have the To or the Files.")
+	}
+	for i, v := range dr.Data.Recipients {
+		if len(v.Files) == 0 {
+			return CodeTypeUnauthorized, errors.New("The recipient " + strconv.Itoa(i+1) + " has no files.")
+		}
+	}
+
+	// the recipients are validated in order, so the same file or the same fresh key
+	// of OtoOPB can not be used twice
+	i, code, err := pba.sequenceValidation(dr.recipientSends(), (*PBApplication).recipientValidation, (*PBApplication).sendActionState)
+	if err != nil {
+		return code, errors.New("The recipient " + strconv.Itoa(i+1) + " is not valid: " + err.Error())
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) recipientValidation(dr DeliveryRequest) (uint32, error) {
+	code, err := ipfsFilesValidation(dr.Data.Files)
+	if err != nil {
+		return code, err
+	}
+	code, err = pba.sendActionValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	if dr.Data.Vesting != nil {
+		return pba.vestingValidation(dr)
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) recipientsState(dr DeliveryRequest) {
+	for _, v := range dr.recipientSends() {
+		pba.sendActionState(v)
+	}
+}
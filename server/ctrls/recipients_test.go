@@ -0,0 +1,120 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbSendToRecipientsSuccessfully(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	bobEdKey := crypto.GenPrivKeyEd25519()
+	aliceEdKey := crypto.GenPrivKeyEd25519()
+
+	input := [][]byte{[]byte("random1"), []byte("random2"), []byte("random3")}
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	files := addDr.Data.Files
+	sendDr := utils.createDelivery(t, fromEdKey, DeliveryData{Action: SEND_ACTION, Recipients: []Recipient{
+		{To: bobEdKey.PubKey().Bytes(), Files: files[:2]},
+		{To: aliceEdKey.PubKey().Address(), Files: files[2:]},
+	}})
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeOK, pba.CheckTx(b).Code)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, bobEdKey, REMOVE_ACTION, input[:2])
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr = utils.createAddOrRemoveDelivery(t, aliceEdKey, REMOVE_ACTION, input[2:])
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbSendToRecipientsFailOnTheSameFileTwice(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	bobEdKey := crypto.GenPrivKeyEd25519()
+	aliceEdKey := crypto.GenPrivKeyEd25519()
+
+	input := [][]byte{[]byte("random1")}
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr := utils.createDelivery(t, fromEdKey, DeliveryData{Action: SEND_ACTION, Recipients: []Recipient{
+		{To: bobEdKey.PubKey().Bytes(), Files: addDr.Data.Files},
+		{To: aliceEdKey.PubKey().Bytes(), Files: addDr.Data.Files},
+	}})
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	// nothing was sent
+	remDr := utils.createAddOrRemoveDelivery(t, fromEdKey, REMOVE_ACTION, input)
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestOtopbSendToRecipientsFailOnTheSameKeyTwice(t *testing.T) {
+	conf.Conf.Blockchain = conf.OtoOPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	firstEdKey := crypto.GenPrivKeyEd25519()
+	secondEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, firstEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	addDr2 := utils.createAddOrRemoveDelivery(t, secondEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	b, _ = json.Marshal(addDr2)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// for one to one blockchain, the key of a recipient can get only one file
+	sendDr := utils.createDelivery(t, firstEdKey, DeliveryData{Action: SEND_ACTION, Recipients: []Recipient{
+		{To: toEdKey.PubKey().Bytes(), Files: addDr.Data.Files},
+		{To: toEdKey.PubKey().Bytes(), Files: addDr.Data.Files},
+	}})
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	sendDr = utils.createDelivery(t, firstEdKey, DeliveryData{Action: SEND_ACTION, Recipients: []Recipient{
+		{To: toEdKey.PubKey().Address(), Files: addDr.Data.Files},
+	}})
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestSpbSendToRecipientsFailWithTheTo(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	bobEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	dd := DeliveryData{}
+	dd.Action = SEND_ACTION
+	dd.From = fromEdKey.PubKey().Bytes()
+	toB := bobEdKey.PubKey().Bytes()
+	dd.To = &toB
+	dd.Recipients = []Recipient{{To: toB, Files: addDr.Data.Files}}
+	b, _ = json.Marshal(dd)
+	sendDr := DeliveryRequest{Signature: fromEdKey.Sign(b).Bytes(), Data: dd}
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
@@ -0,0 +1,225 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/tendermint/go-crypto"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+func (pba *PBApplication) getGuardianSet(addr string) *GuardianSet {
+	b := pba.state.db.Get(prefixGuardianKey(addr))
+	if len(b) == 0 {
+		return nil
+	}
+	gs := GuardianSet{}
+	json.Unmarshal(b, &gs)
+	return &gs
+}
+
+// moveGuardians gives the guardians of the old address to the new one,
+// and the new address takes the place of the old one in the guardians of the other accounts.
+func (pba *PBApplication) moveGuardians(oldAddr, newAddr string) {
+	if gs := pba.getGuardianSet(oldAddr); gs != nil {
+		pba.state.db.Delete(prefixGuardianKey(oldAddr))
+		if pba.getGuardianSet(newAddr) == nil {
+			b, _ := json.Marshal(gs)
+			pba.state.db.Set(prefixGuardianKey(newAddr), b)
+		}
+	}
+
+	keys := [][]byte{}
+	sets := []GuardianSet{}
+	itr := dbm.IteratePrefix(pba.state.db, guardianKey)
+	for ; itr.Valid(); itr.Next() {
+		gs := GuardianSet{}
+		json.Unmarshal(itr.Value(), &gs)
+		account := string(itr.Key()[len(guardianKey):])
+		addresses := []string{}
+		seen := map[string]bool{}
+		for _, v := range gs.Addresses {
+			if v == oldAddr {
+				v = newAddr
+			}
+			// the account can not be its own guardian and the guardians need to be different
+			if v != account && !seen[v] {
+				seen[v] = true
+				addresses = append(addresses, v)
+			}
+		}
+		if seen[newAddr] || len(addresses) != len(gs.Addresses) {
+			gs.Addresses = addresses
+			if gs.Threshold > len(addresses) {
+				gs.Threshold = len(addresses)
+			}
+			keys = append(keys, itr.Key())
+			sets = append(sets, gs)
+		}
+	}
+	itr.Close()
+
+	for i, k := range keys {
+		if len(sets[i].Addresses) == 0 {
+			pba.state.db.Delete(k)
+			continue
+		}
+		b, _ := json.Marshal(sets[i])
+		pba.state.db.Set(k, b)
+	}
+}
+
+func (pba *PBApplication) guardiansActionValidation(dr DeliveryRequest) (uint32, error) {
+	gs := dr.Data.Guardians
+	if gs == nil {
+		return CodeTypeUnauthorized, errors.New("The guardians do not exist.")
+	}
+	if gs.Threshold < 1 || gs.Threshold > len(gs.Addresses) {
+		return CodeTypeUnauthorized, errors.New("The threshold needs to be between one and the number of guardians.")
+	}
+	if gs.Delay < 1 {
+		return CodeTypeUnauthorized, errors.New("The delay needs to be at least one block.")
+	}
+	fromAddr, _ := dr.FromPubKeyAddress()
+	guardians := map[string]bool{}
+	for _, v := range gs.Addresses {
+		if v == fromAddr {
+			return CodeTypeUnauthorized, errors.New("You can not be your own guardian.")
+		}
+		guardians[v] = true
+	}
+	if len(guardians) != len(gs.Addresses) {
+		return CodeTypeUnauthorized, errors.New("The addresses of the guardians need to be different.")
+	}
+	return CodeTypeOK, nil
+}
+
+// recoveryActionValidation checks that enough guardians of the account
+// have signed the recovery to the new key, the To.
+func (pba *PBApplication) recoveryActionValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.LostAccount == nil {
+		return CodeTypeUnauthorized, errors.New("The address of the lost account does not exists.")
+	}
+	account := *dr.Data.LostAccount
+	gs := pba.getGuardianSet(account)
+	if gs == nil {
+		return CodeTypeUnauthorized, errors.New("The account " + account + " does not have guardians.")
+	}
+	if pba.state.db.Has(prefixRecoveryKey(account)) {
+		return CodeTypeUnauthorized, errors.New("The account " + account + " is already in recovery.")
+	}
+
+	if dr.Data.To == nil {
+		return CodeTypeUnauthorized, errors.New("The public key of the new key does not exists.")
+	}
+	to, err := crypto.PubKeyFromBytes(*dr.Data.To)
+	if err != nil {
+		return CodeTypeEncodingError, errors.New("The public key of the new key is not correct.")
+	}
+	code, err := pba.recoverableValidation(account, to.Address().String())
+	if err != nil {
+		return code, err
+	}
+
+	signers := dr.SignerAddresses()
+	signed := 0
+	for _, v := range gs.Addresses {
+		if signers[v] {
+			signed++
+		}
+	}
+	if signed < gs.Threshold {
+		return CodeTypeUnauthorized, errors.New("The recovery needs more signatures from the guardians.")
+	}
+	return CodeTypeOK, nil
+}
+
+// recoverableValidation checks that the files of the account can move to the new address,
+// when the guardians ask the recovery and again when it executes after the delay.
+// Like the rotation, the files keep their locks, leases and vestings, only the frozen and the banned files stop it.
+func (pba *PBApplication) recoverableValidation(account, newAddr string) (uint32, error) {
+	if pba.state.db.Has(prefixRetiredKey(account)) {
+		return CodeTypeUnauthorized, errors.New("The account " + account + " is retired.")
+	}
+	if newAddr == account {
+		return CodeTypeUnauthorized, errors.New("The new key is the same as the account.")
+	}
+	if pba.state.db.Has(prefixRetiredKey(newAddr)) {
+		return CodeTypeUnauthorized, errors.New("The address of the new key is retired.")
+	}
+	code, err := pba.registeredValidation(newAddr)
+	if err != nil {
+		return code, err
+	}
+	if conf.Conf.Blockchain == conf.OtoOPB && pba.state.db.Has(prefixUserKey(newAddr)) {
+		return CodeTypeUnauthorized, errors.New("The public key of the receiver exists in the DB.")
+	}
+	code, err = pba.frozenAccountValidation(account)
+	if err != nil {
+		return code, err
+	}
+	return pba.bannedFilesValidation(pba.getUserFiles(account))
+}
+
+func (pba *PBApplication) recoveryCancelActionValidation(dr DeliveryRequest) (uint32, error) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	if !pba.state.db.Has(prefixRecoveryKey(fromAddr)) {
+		return CodeTypeUnauthorized, errors.New("There is no recovery for your account.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) guardiansActionState(dr DeliveryRequest) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	b, _ := json.Marshal(dr.Data.Guardians)
+	pba.state.db.Set(prefixGuardianKey(fromAddr), b)
+}
+
+func (pba *PBApplication) recoveryActionState(dr DeliveryRequest) {
+	account := *dr.Data.LostAccount
+	gs := pba.getGuardianSet(account)
+	toAddr, _ := dr.ToPubKeyAddress()
+	r := Recovery{
+		NewAddr: toAddr,
+		Height:  pba.currentHeight() + gs.Delay,
+	}
+	b, _ := json.Marshal(r)
+	pba.state.db.Set(prefixRecoveryKey(account), b)
+}
+
+func (pba *PBApplication) recoveryCancelActionState(dr DeliveryRequest) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	pba.state.db.Delete(prefixRecoveryKey(fromAddr))
+}
+
+// executeRecoveries moves the files of the accounts that their recovery
+// was not canceled until the end of its block.
+func (pba *PBApplication) executeRecoveries(height int64) {
+	// the iterator keeps the order of the keys, so every node executes them the same way
+	accounts := []string{}
+	recoveries := []Recovery{}
+	itr := dbm.IteratePrefix(pba.state.db, recoveryKey)
+	for ; itr.Valid(); itr.Next() {
+		r := Recovery{}
+		json.Unmarshal(itr.Value(), &r)
+		if r.Height <= height {
+			accounts = append(accounts, string(itr.Key()[len(recoveryKey):]))
+			recoveries = append(recoveries, r)
+		}
+	}
+	itr.Close()
+
+	for i, account := range accounts {
+		pba.state.db.Delete(prefixRecoveryKey(account))
+		// the new key could have rotated during the delay, and the account or its files
+		// could have been frozen, banned or retired, so the recovery is dropped when it can not move them
+		newAddr := pba.activeAddress(recoveries[i].NewAddr)
+		if _, err := pba.recoverableValidation(account, newAddr); err != nil {
+			log.Println("The recovery of the account " + account + " was dropped: " + err.Error())
+			continue
+		}
+		pba.retireAddress(account, newAddr)
+	}
+}
@@ -0,0 +1,229 @@
+package ctrls
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbRecoveryMovesTheFilesAfterTheDelay(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	lostEdKey := crypto.GenPrivKeyEd25519()
+	newEdKey := crypto.GenPrivKeyEd25519()
+	guardians := []crypto.PrivKeyEd25519{
+		crypto.GenPrivKeyEd25519(),
+		crypto.GenPrivKeyEd25519(),
+		crypto.GenPrivKeyEd25519(),
+	}
+
+	input := [][]byte{[]byte("random1")}
+	addDr := utils.createAddOrRemoveDelivery(t, lostEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	guardiansDr := utils.createDelivery(t, lostEdKey, DeliveryData{
+		Action:    GUARDIANS_ACTION,
+		Guardians: &GuardianSet{Addresses: utils.addresses(guardians...), Threshold: 2, Delay: 2},
+	})
+	b, _ = json.Marshal(guardiansDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	recoveryDr := utils.createDelivery(t, guardians[0], DeliveryData{
+		Action:      RECOVERY_ACTION,
+		LostAccount: utils.str(utils.address(lostEdKey)),
+		To:          utils.pubKey(newEdKey),
+	})
+	b, _ = json.Marshal(recoveryDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	recoveryDr = utils.createDelivery(t, guardians[1], DeliveryData{
+		Action:      RECOVERY_ACTION,
+		LostAccount: utils.str(utils.address(lostEdKey)),
+		To:          utils.pubKey(newEdKey),
+	}, guardians[2:]...)
+	b, _ = json.Marshal(recoveryDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the delay has not passed yet
+	pba.EndBlock(types.RequestEndBlock{Height: 2})
+	remDr := utils.createAddOrRemoveDelivery(t, newEdKey, REMOVE_ACTION, input)
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	pba.EndBlock(types.RequestEndBlock{Height: 3})
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbRecoveryCanceledByTheAccount(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	accountEdKey := crypto.GenPrivKeyEd25519()
+	attackerEdKey := crypto.GenPrivKeyEd25519()
+	guardians := []crypto.PrivKeyEd25519{
+		crypto.GenPrivKeyEd25519(),
+		crypto.GenPrivKeyEd25519(),
+	}
+
+	input := [][]byte{[]byte("random1")}
+	addDr := utils.createAddOrRemoveDelivery(t, accountEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	guardiansDr := utils.createDelivery(t, accountEdKey, DeliveryData{
+		Action:    GUARDIANS_ACTION,
+		Guardians: &GuardianSet{Addresses: utils.addresses(guardians...), Threshold: 2, Delay: 2},
+	})
+	b, _ = json.Marshal(guardiansDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	recoveryDr := utils.createDelivery(t, guardians[0], DeliveryData{
+		Action:      RECOVERY_ACTION,
+		LostAccount: utils.str(utils.address(accountEdKey)),
+		To:          utils.pubKey(attackerEdKey),
+	}, guardians[1:]...)
+	b, _ = json.Marshal(recoveryDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	dd := DeliveryData{}
+	dd.Action = RECOVERY_CANCEL_ACTION
+	dd.From = accountEdKey.PubKey().Bytes()
+	b, _ = json.Marshal(dd)
+	cancelDr := DeliveryRequest{Signature: accountEdKey.Sign(b).Bytes(), Data: dd}
+	b, _ = json.Marshal(cancelDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	pba.EndBlock(types.RequestEndBlock{Height: 3})
+	remDr := utils.createAddOrRemoveDelivery(t, accountEdKey, REMOVE_ACTION, input)
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbRecoveryFollowsTheRotationOfTheNewKey(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	lostEdKey := crypto.GenPrivKeyEd25519()
+	newEdKey := crypto.GenPrivKeyEd25519()
+	rotatedEdKey := crypto.GenPrivKeyEd25519()
+	guardianEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, lostEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files", addDr, CodeTypeOK},
+		{"set the guardians", utils.createDelivery(t, lostEdKey, DeliveryData{
+			Action:    GUARDIANS_ACTION,
+			Guardians: &GuardianSet{Addresses: utils.addresses(guardianEdKey), Threshold: 1, Delay: 1},
+		}), CodeTypeOK},
+		{"recover the account", utils.createDelivery(t, guardianEdKey, DeliveryData{
+			Action:      RECOVERY_ACTION,
+			LostAccount: utils.str(utils.address(lostEdKey)),
+			To:          utils.pubKey(newEdKey),
+		}), CodeTypeOK},
+		{"the new key rotates during the delay", utils.coSign(utils.createDelivery(t, newEdKey, DeliveryData{
+			Action: ROTATE_ACTION,
+			To:     utils.pubKey(rotatedEdKey),
+		}), rotatedEdKey), CodeTypeOK},
+	})
+
+	pba.EndBlock(types.RequestEndBlock{Height: 2})
+	assert.Equal(t, []string{}, pba.getUserFiles(utils.address(newEdKey)))
+	assert.Equal(t, addDr.Data.Files, pba.getUserFiles(utils.address(rotatedEdKey)))
+}
+
+func TestSpbRecoveryDroppedWhenTheNewKeyRotatesBackToTheAccount(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	lostEdKey := crypto.GenPrivKeyEd25519()
+	newEdKey := crypto.GenPrivKeyEd25519()
+	guardianEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, lostEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files", addDr, CodeTypeOK},
+		{"set the guardians", utils.createDelivery(t, lostEdKey, DeliveryData{
+			Action:    GUARDIANS_ACTION,
+			Guardians: &GuardianSet{Addresses: utils.addresses(guardianEdKey), Threshold: 1, Delay: 1},
+		}), CodeTypeOK},
+		{"recover the account", utils.createDelivery(t, guardianEdKey, DeliveryData{
+			Action:      RECOVERY_ACTION,
+			LostAccount: utils.str(utils.address(lostEdKey)),
+			To:          utils.pubKey(newEdKey),
+		}), CodeTypeOK},
+		{"the new key rotates to the account", utils.coSign(utils.createDelivery(t, newEdKey, DeliveryData{
+			Action: ROTATE_ACTION,
+			To:     utils.pubKey(lostEdKey),
+		}), lostEdKey), CodeTypeOK},
+	})
+
+	// the recovery would retire the account to itself, so it is dropped
+	pba.EndBlock(types.RequestEndBlock{Height: 2})
+	assert.False(t, pba.state.db.Has(prefixRecoveryKey(utils.address(lostEdKey))))
+	assert.False(t, pba.state.db.Has(prefixRetiredKey(utils.address(lostEdKey))))
+	assert.Equal(t, addDr.Data.Files, pba.getUserFiles(utils.address(lostEdKey)))
+}
+
+func TestSpbRecoveryMovesTheLockedFilesAndRefusesTheFrozenFiles(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	lostEdKey := crypto.GenPrivKeyEd25519()
+	lockedEdKey := crypto.GenPrivKeyEd25519()
+	newEdKey := crypto.GenPrivKeyEd25519()
+	guardianEdKey := crypto.GenPrivKeyEd25519()
+	secretHash := sha256.Sum256([]byte("the secret"))
+	recovery := func(account crypto.PrivKeyEd25519) DeliveryRequest {
+		return utils.createDelivery(t, guardianEdKey, DeliveryData{
+			Action:      RECOVERY_ACTION,
+			LostAccount: utils.str(utils.address(account)),
+			To:          utils.pubKey(newEdKey),
+		})
+	}
+	guardians := func(account crypto.PrivKeyEd25519) DeliveryRequest {
+		return utils.createDelivery(t, account, DeliveryData{
+			Action:    GUARDIANS_ACTION,
+			Guardians: &GuardianSet{Addresses: utils.addresses(guardianEdKey), Threshold: 1, Delay: 1},
+		})
+	}
+
+	addDr := utils.createAddOrRemoveDelivery(t, lostEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	lockedAddDr := utils.createAddOrRemoveDelivery(t, lockedEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files", addDr, CodeTypeOK},
+		{"add the files that will be locked", lockedAddDr, CodeTypeOK},
+		{"set the guardians", guardians(lostEdKey), CodeTypeOK},
+		{"set the guardians of the locked account", guardians(lockedEdKey), CodeTypeOK},
+		{"lock the files", utils.createDelivery(t, lockedEdKey, DeliveryData{
+			Action:     HTLC_LOCK_ACTION,
+			To:         utils.pubKey(crypto.GenPrivKeyEd25519()),
+			Files:      lockedAddDr.Data.Files,
+			SecretHash: secretHash[:],
+			Timeout:    10,
+		}), CodeTypeOK},
+		{"the locked files do not stop the recovery", recovery(lockedEdKey), CodeTypeOK},
+		{"recover the account", recovery(lostEdKey), CodeTypeOK},
+		{"freeze the files during the delay", utils.createDelivery(t, adminEdKey, DeliveryData{
+			Action: FREEZE_ACTION,
+			Reason: utils.str("court order 123"),
+			Files:  addDr.Data.Files,
+		}), CodeTypeOK},
+	})
+
+	// the frozen files stay with the account, the locked files move with their lock
+	pba.EndBlock(types.RequestEndBlock{Height: 2})
+	assert.False(t, pba.state.db.Has(prefixRecoveryKey(utils.address(lostEdKey))))
+	assert.False(t, pba.state.db.Has(prefixRetiredKey(utils.address(lostEdKey))))
+	assert.Equal(t, addDr.Data.Files, pba.getUserFiles(utils.address(lostEdKey)))
+	assert.True(t, pba.state.db.Has(prefixRetiredKey(utils.address(lockedEdKey))))
+	assert.Equal(t, lockedAddDr.Data.Files, pba.getUserFiles(utils.address(newEdKey)))
+	assert.True(t, pba.state.db.Has(prefixLockKey(lockedAddDr.Data.Files[0])))
+}
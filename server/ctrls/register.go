@@ -0,0 +1,44 @@
+package ctrls
+
+import (
+	"errors"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+)
+
+// registeredValidation refuses the addresses that the admins have not registered,
+// when the chain is permissioned.
+func (pba *PBApplication) registeredValidation(addr string) (uint32, error) {
+	if conf.Conf.Permissioned && !pba.state.db.Has(prefixRegisterKey(addr)) {
+		return CodeTypeUnauthorized, errors.New("The address " + addr + " is not registered.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) registerActionValidation(dr DeliveryRequest) (uint32, error) {
+	code, err := pba.adminValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	if dr.Data.Account == nil || len(*dr.Data.Account) == 0 {
+		return CodeTypeUnauthorized, errors.New("The address to register does not exists.")
+	}
+	account := *dr.Data.Account
+	registered := pba.state.db.Has(prefixRegisterKey(account))
+	if dr.Data.Action == REGISTER_ACTION && registered {
+		return CodeTypeUnauthorized, errors.New("The address " + account + " is already registered.")
+	}
+	if dr.Data.Action == UNREGISTER_ACTION && !registered {
+		return CodeTypeUnauthorized, errors.New("The address " + account + " is not registered.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) registerActionState(dr DeliveryRequest) {
+	if dr.Data.Action == REGISTER_ACTION {
+		pba.state.db.Set(prefixRegisterKey(*dr.Data.Account), []byte(*dr.Data.Reason))
+	} else {
+		pba.state.db.Delete(prefixRegisterKey(*dr.Data.Account))
+	}
+	pba.audit(dr, "")
+}
@@ -0,0 +1,97 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/tendermint/go-crypto"
+)
+
+func (pba *PBApplication) getUserFiles(addr string) []string {
+	filesBy := pba.state.db.Get(prefixUserKey(addr))
+	files := []string{}
+	json.Unmarshal(filesBy, &files)
+	return files
+}
+
+// rotateActionValidation checks that the old key, the From, and the new key, the To,
+// have signed the rotation and that all the files of the old key can be moved.
+// The files keep their locks, leases and vestings, only the frozen and the banned files stop the rotation.
+func (pba *PBApplication) rotateActionValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.To == nil {
+		return CodeTypeUnauthorized, errors.New("The public key of the new key does not exists.")
+	}
+	to, err := crypto.PubKeyFromBytes(*dr.Data.To)
+	if err != nil {
+		return CodeTypeEncodingError, errors.New("The public key of the new key is not correct.")
+	}
+
+	b, _ := json.Marshal(dr.Data)
+	sig, err := crypto.SignatureFromBytes(dr.CoSignature)
+	if err != nil {
+		return CodeTypeEncodingError, errors.New("The signature of the new key is not correct.")
+	}
+	if !to.VerifyBytes(b, sig) {
+		return CodeTypeUnauthorized, errors.New("The signature of the new key does not validate the data.")
+	}
+
+	fromAddr, _ := dr.FromPubKeyAddress()
+	code, err := pba.addressesValidation(fromAddr, to.Address().String())
+	if err != nil {
+		return code, err
+	}
+	code, err = pba.frozenAccountValidation(fromAddr)
+	if err != nil {
+		return code, err
+	}
+	return pba.bannedFilesValidation(pba.getUserFiles(fromAddr))
+}
+
+func (pba *PBApplication) rotateActionState(dr DeliveryRequest) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	toAddr, _ := dr.ToPubKeyAddress()
+	pba.retireAddress(fromAddr, toAddr)
+}
+
+// retireAddress moves all the files of the old address to the new one
+// and refuses any delivery from or to the old address after that.
+// The locks, the escrows, the approvals, the guardians and the inheritance move to the new address,
+// the leases of the old address return to activeAddress of their owner when they expire.
+func (pba *PBApplication) retireAddress(oldAddr, newAddr string) {
+	pba.moveHoldings(oldAddr, newAddr)
+	pba.moveApprovals(oldAddr, newAddr)
+	pba.moveGuardians(oldAddr, newAddr)
+	pba.moveInheritance(oldAddr, newAddr)
+	pba.state.db.Set(prefixRetiredKey(oldAddr), []byte(newAddr))
+}
+
+// moveHoldings moves the files of the old address with their locks, leases and vestings
+// and the escrows of the old address to the new one.
+// The vestings stay on the files and the leases follow the retirement of the old address.
+func (pba *PBApplication) moveHoldings(oldAddr, newAddr string) {
+	pba.transferAllFiles(oldAddr, newAddr)
+	pba.moveHashLocks(oldAddr, newAddr)
+	pba.moveEscrows(oldAddr, newAddr)
+}
+
+// activeAddress follows the rotations of the address to the one that is not retired.
+// It stops at the first address that it has seen again, so a cycle returns a retired address.
+func (pba *PBApplication) activeAddress(addr string) string {
+	seen := map[string]bool{}
+	for !seen[addr] {
+		seen[addr] = true
+		next := pba.state.db.Get(prefixRetiredKey(addr))
+		if len(next) == 0 {
+			return addr
+		}
+		addr = string(next)
+	}
+	return addr
+}
+
+func (pba *PBApplication) transferAllFiles(oldAddr, newAddr string) {
+	files := pba.getUserFiles(oldAddr)
+	if len(files) > 0 {
+		pba.transferFiles(oldAddr, newAddr, files)
+	}
+}
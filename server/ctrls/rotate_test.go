@@ -0,0 +1,259 @@
+package ctrls
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbRotateMovesAllTheFiles(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	oldEdKey := crypto.GenPrivKeyEd25519()
+	newEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	input := [][]byte{[]byte("random1"), []byte("random2")}
+	addDr := utils.createAddOrRemoveDelivery(t, oldEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	rotateDr := utils.coSign(utils.createDelivery(t, oldEdKey, DeliveryData{
+		Action: ROTATE_ACTION,
+		To:     utils.pubKey(newEdKey),
+	}), newEdKey)
+	b, _ = json.Marshal(rotateDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, newEdKey, REMOVE_ACTION, input)
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbRotateRefusesTheRetiredAddress(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	oldEdKey := crypto.GenPrivKeyEd25519()
+	newEdKey := crypto.GenPrivKeyEd25519()
+	otherEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	rotateDr := utils.coSign(utils.createDelivery(t, oldEdKey, DeliveryData{
+		Action: ROTATE_ACTION,
+		To:     utils.pubKey(newEdKey),
+	}), newEdKey)
+	b, _ := json.Marshal(rotateDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	addDr := utils.createAddOrRemoveDelivery(t, otherEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr := utils.createSendDelivery(t, otherEdKey, &oldEdKey, SEND_ACTION, addDr.Data.Files)
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	addDr = utils.createAddOrRemoveDelivery(t, oldEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestSpbRotateFailWithoutTheNewKeysSignature(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	oldEdKey := crypto.GenPrivKeyEd25519()
+	newEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	rotateDr := utils.coSign(utils.createDelivery(t, oldEdKey, DeliveryData{
+		Action: ROTATE_ACTION,
+		To:     utils.pubKey(newEdKey),
+	}), newEdKey)
+	rotateDr.CoSignature = oldEdKey.Sign([]byte("other")).Bytes()
+	b, _ := json.Marshal(rotateDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestSpbRotateMovesTheLocksAndTheLeases(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	oldEdKey := crypto.GenPrivKeyEd25519()
+	newEdKey := crypto.GenPrivKeyEd25519()
+	otherEdKey := crypto.GenPrivKeyEd25519()
+	lesseeEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+	secret := []byte("the secret")
+	secretHash := sha256.Sum256(secret)
+
+	addDr := utils.createAddOrRemoveDelivery(t, oldEdKey, ADD_ACTION,
+		[][]byte{[]byte("random1"), []byte("random2")})
+	otherAddDr := utils.createAddOrRemoveDelivery(t, otherEdKey, ADD_ACTION, [][]byte{[]byte("random3")})
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files", addDr, CodeTypeOK},
+		{"add the files of the other", otherAddDr, CodeTypeOK},
+		{"lock the hash for the other", utils.createDelivery(t, oldEdKey, DeliveryData{
+			Action:     HTLC_LOCK_ACTION,
+			To:         utils.pubKey(otherEdKey),
+			Files:      addDr.Data.Files[:1],
+			SecretHash: secretHash[:],
+			Timeout:    10,
+		}), CodeTypeOK},
+		{"the other locks a hash for the old key", utils.createDelivery(t, otherEdKey, DeliveryData{
+			Action:     HTLC_LOCK_ACTION,
+			To:         utils.pubKey(oldEdKey),
+			Files:      otherAddDr.Data.Files,
+			SecretHash: secretHash[:],
+			Timeout:    10,
+		}), CodeTypeOK},
+		{"lease the hash", utils.createDelivery(t, oldEdKey, DeliveryData{
+			Action:      LEASE_ACTION,
+			To:          utils.pubKey(lesseeEdKey),
+			Files:       addDr.Data.Files[1:],
+			LeaseExpiry: 2,
+		}), CodeTypeOK},
+		{"the locked hash does not stop the rotation", utils.coSign(utils.createDelivery(t, oldEdKey, DeliveryData{
+			Action: ROTATE_ACTION,
+			To:     utils.pubKey(newEdKey),
+		}), newEdKey), CodeTypeOK},
+		{"the other claims the hash of the new key", utils.createDelivery(t, otherEdKey, DeliveryData{
+			Action: HTLC_CLAIM_ACTION,
+			Files:  addDr.Data.Files[:1],
+			Secret: secret,
+		}), CodeTypeOK},
+		{"the new key claims the hash that was locked for the old key", utils.createDelivery(t, newEdKey, DeliveryData{
+			Action: HTLC_CLAIM_ACTION,
+			Files:  otherAddDr.Data.Files,
+			Secret: secret,
+		}), CodeTypeOK},
+	})
+
+	// the lease resolves its owner with activeAddress, so the hash returns to the new key
+	pba.EndBlock(types.RequestEndBlock{Height: 2})
+	assert.Equal(t, append(otherAddDr.Data.Files, addDr.Data.Files[1:]...), pba.getUserFiles(utils.address(newEdKey)))
+	assert.Equal(t, addDr.Data.Files[:1], pba.getUserFiles(utils.address(otherEdKey)))
+}
+
+func TestSpbRotateMovesTheApprovalsTheGuardiansAndTheInheritance(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	oldEdKey := crypto.GenPrivKeyEd25519()
+	newEdKey := crypto.GenPrivKeyEd25519()
+	otherEdKey := crypto.GenPrivKeyEd25519()
+	friendEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	addDr := utils.createAddOrRemoveDelivery(t, oldEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	otherAddDr := utils.createAddOrRemoveDelivery(t, otherEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files", addDr, CodeTypeOK},
+		{"add the files of the other", otherAddDr, CodeTypeOK},
+		{"approve the friend", utils.createDelivery(t, oldEdKey, DeliveryData{
+			Action:   APPROVE_ACTION,
+			Approval: &Approval{Delegate: utils.address(friendEdKey), Count: 1},
+		}), CodeTypeOK},
+		{"the other approves the old key", utils.createDelivery(t, otherEdKey, DeliveryData{
+			Action:   APPROVE_ACTION,
+			Approval: &Approval{Delegate: utils.address(oldEdKey), Count: 1},
+		}), CodeTypeOK},
+		{"the friend is the guardian", utils.createDelivery(t, oldEdKey, DeliveryData{
+			Action:    GUARDIANS_ACTION,
+			Guardians: &GuardianSet{Addresses: utils.addresses(friendEdKey), Threshold: 1, Delay: 5},
+		}), CodeTypeOK},
+		{"the old key is the guardian of the other", utils.createDelivery(t, otherEdKey, DeliveryData{
+			Action:    GUARDIANS_ACTION,
+			Guardians: &GuardianSet{Addresses: utils.addresses(oldEdKey), Threshold: 1, Delay: 5},
+		}), CodeTypeOK},
+		{"the friend is the beneficiary", utils.createDelivery(t, oldEdKey, DeliveryData{
+			Action:      INHERITANCE_ACTION,
+			Inheritance: &InheritancePlan{Beneficiary: utils.address(friendEdKey), Period: 5},
+		}), CodeTypeOK},
+		{"the old key is the beneficiary of the other", utils.createDelivery(t, otherEdKey, DeliveryData{
+			Action:      INHERITANCE_ACTION,
+			Inheritance: &InheritancePlan{Beneficiary: utils.address(oldEdKey), Period: 5},
+		}), CodeTypeOK},
+		{"rotate the key", utils.coSign(utils.createDelivery(t, oldEdKey, DeliveryData{
+			Action: ROTATE_ACTION,
+			To:     utils.pubKey(newEdKey),
+		}), newEdKey), CodeTypeOK},
+		{"the friend sends from the new key", utils.createDelivery(t, friendEdKey, DeliveryData{
+			Action: SEND_FROM_ACTION,
+			Owner:  utils.str(utils.address(newEdKey)),
+			To:     utils.pubKey(friendEdKey),
+			Files:  addDr.Data.Files,
+		}), CodeTypeOK},
+		{"the new key sends from the other", utils.createDelivery(t, newEdKey, DeliveryData{
+			Action: SEND_FROM_ACTION,
+			Owner:  utils.str(utils.address(otherEdKey)),
+			To:     utils.pubKey(newEdKey),
+			Files:  otherAddDr.Data.Files,
+		}), CodeTypeOK},
+		{"the friend recovers the new key", utils.createDelivery(t, friendEdKey, DeliveryData{
+			Action:      RECOVERY_ACTION,
+			LostAccount: utils.str(utils.address(newEdKey)),
+			To:          utils.pubKey(crypto.GenPrivKeyEd25519()),
+		}), CodeTypeOK},
+		{"the new key recovers the other", utils.createDelivery(t, newEdKey, DeliveryData{
+			Action:      RECOVERY_ACTION,
+			LostAccount: utils.str(utils.address(otherEdKey)),
+			To:          utils.pubKey(crypto.GenPrivKeyEd25519()),
+		}), CodeTypeOK},
+	})
+
+	newAddr := utils.address(newEdKey)
+	assert.Nil(t, pba.getInheritancePlan(utils.address(oldEdKey)))
+	assert.Equal(t, &InheritancePlan{Beneficiary: utils.address(friendEdKey), Period: 5}, pba.getInheritancePlan(newAddr))
+	assert.Equal(t, newAddr, pba.getInheritancePlan(utils.address(otherEdKey)).Beneficiary)
+	// the rotation is an activity of the new key, so its inheritance can not be claimed before the period
+	assert.Equal(t, int64(1), pba.lastActivity(newAddr))
+}
+
+func TestSpbRotateFailWhenTheAccountIsFrozen(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	oldEdKey := crypto.GenPrivKeyEd25519()
+	newEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, oldEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	rotateDr := utils.coSign(utils.createDelivery(t, oldEdKey, DeliveryData{
+		Action: ROTATE_ACTION,
+		To:     utils.pubKey(newEdKey),
+	}), newEdKey)
+	utils.deliverCases(t, pba, []deliveryCase{
+		{"add the files", addDr, CodeTypeOK},
+		{"ban the files", utils.createDelivery(t, adminEdKey, DeliveryData{
+			Action: BAN_ACTION,
+			Reason: utils.str("counterfeit note template"),
+			Files:  addDr.Data.Files,
+		}), CodeTypeOK},
+		{"the banned files stop the rotation", rotateDr, CodeTypeUnauthorized},
+		{"unban the files", utils.createDelivery(t, adminEdKey, DeliveryData{
+			Action: UNBAN_ACTION,
+			Reason: utils.str("the content was reviewed"),
+			Files:  addDr.Data.Files,
+		}), CodeTypeOK},
+		{"freeze the account", utils.createDelivery(t, adminEdKey, DeliveryData{
+			Action:  FREEZE_ACTION,
+			Reason:  utils.str("court order 123"),
+			Account: utils.str(utils.address(oldEdKey)),
+		}), CodeTypeOK},
+		{"the frozen account can not rotate", rotateDr, CodeTypeUnauthorized},
+	})
+}
+
+func TestActiveAddressStopsOnACycle(t *testing.T) {
+	pba := NewPBApplication()
+	pba.state.db.Set(prefixRetiredKey("a"), []byte("b"))
+	pba.state.db.Set(prefixRetiredKey("b"), []byte("a"))
+	pba.state.db.Set(prefixRetiredKey("c"), []byte("a"))
+
+	assert.Equal(t, "a", pba.activeAddress("c"))
+	assert.True(t, pba.state.db.Has(prefixRetiredKey(pba.activeAddress("a"))))
+	assert.Equal(t, "d", pba.activeAddress("d"))
+}
@@ -0,0 +1,133 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ipfs/go-ipfs-api"
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+// readSchema reads the json schema of the hash from the IPFS.
+func readSchema(hash string) (*JsonSchema, error) {
+	sh := shell.NewShell(conf.Conf.IpfsConnection)
+	b, err := sh.BlockGet(hash)
+	if err != nil {
+		return nil, errors.New("The schema " + hash + " does not exists.")
+	}
+	return parseJsonSchema(b)
+}
+
+// getSchemas returns the hashes of the registered schemas in order.
+func (pba *PBApplication) getSchemas() []string {
+	schemas := []string{}
+	itr := dbm.IteratePrefix(pba.state.db, schemaKey)
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		schemas = append(schemas, string(itr.Key()[len(schemaKey):]))
+	}
+	return schemas
+}
+
+func (pba *PBApplication) fileSchemas(files []string) []FileSchema {
+	fss := []FileSchema{}
+	for _, v := range files {
+		if schema := pba.state.db.Get(prefixAttestKey(v)); len(schema) > 0 {
+			fss = append(fss, FileSchema{File: v, Schema: string(schema)})
+		}
+	}
+	return fss
+}
+
+// schemaValidation validates the json files against the Schema of the delivery,
+// when there are registered schemas a json object can not be added without one.
+// The other files, and the json values that are not objects, do not need a Schema.
+func (pba *PBApplication) schemaValidation(dr DeliveryRequest) (uint32, error) {
+	var js *JsonSchema
+	if dr.Data.Schema != nil {
+		if !pba.state.db.Has(prefixSchemaKey(*dr.Data.Schema)) {
+			return CodeTypeUnauthorized, errors.New("The schema " + *dr.Data.Schema + " is not registered.")
+		}
+		var err error
+		js, err = readSchema(*dr.Data.Schema)
+		if err != nil {
+			return CodeTypeEncodingError, err
+		}
+	}
+	if js == nil && len(pba.getSchemas()) == 0 {
+		return CodeTypeOK, nil
+	}
+
+	sh := shell.NewShell(conf.Conf.IpfsConnection)
+	for _, v := range dr.Data.Files {
+		b, err := sh.BlockGet(v)
+		if err != nil {
+			return CodeTypeEncodingError, errors.New("The file " + v + " does not exists.")
+		}
+		var value interface{}
+		isJson := json.Unmarshal(b, &value) == nil
+		if js == nil {
+			// only the json objects need a schema
+			if _, ok := value.(map[string]interface{}); ok {
+				return CodeTypeUnauthorized, errors.New("The json " + v + " needs a registered schema.")
+			}
+			continue
+		}
+		if !isJson {
+			return CodeTypeEncodingError, errors.New("The hash " + v + " is not a json.")
+		}
+		err = js.validate(value, "The json "+v)
+		if err != nil {
+			return CodeTypeEncodingError, err
+		}
+	}
+	return CodeTypeOK, nil
+}
+
+// attestSchema records the schema that validated the added files.
+func (pba *PBApplication) attestSchema(dr DeliveryRequest) {
+	if dr.Data.Schema == nil {
+		return
+	}
+	for _, v := range dr.Data.Files {
+		pba.state.db.Set(prefixAttestKey(v), []byte(*dr.Data.Schema))
+	}
+}
+
+func (pba *PBApplication) schemaActionValidation(dr DeliveryRequest) (uint32, error) {
+	code, err := pba.adminValidation(dr)
+	if err != nil {
+		return code, err
+	}
+	if len(dr.Data.Files) == 0 {
+		return CodeTypeUnauthorized, errors.New("There are no schemas.")
+	}
+	for _, v := range dr.Data.Files {
+		registered := pba.state.db.Has(prefixSchemaKey(v))
+		if dr.Data.Action == SCHEMA_REGISTER_ACTION && registered {
+			return CodeTypeUnauthorized, errors.New("The schema " + v + " is already registered.")
+		}
+		if dr.Data.Action == SCHEMA_UNREGISTER_ACTION && !registered {
+			return CodeTypeUnauthorized, errors.New("The schema " + v + " is not registered.")
+		}
+		if dr.Data.Action == SCHEMA_REGISTER_ACTION {
+			_, err := readSchema(v)
+			if err != nil {
+				return CodeTypeEncodingError, err
+			}
+		}
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) schemaActionState(dr DeliveryRequest) {
+	for _, v := range dr.Data.Files {
+		if dr.Data.Action == SCHEMA_REGISTER_ACTION {
+			pba.state.db.Set(prefixSchemaKey(v), []byte{1})
+		} else {
+			pba.state.db.Delete(prefixSchemaKey(v))
+		}
+	}
+	pba.audit(dr, "")
+}
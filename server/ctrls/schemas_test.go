@@ -0,0 +1,173 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+const noteSchema = `{
+	"type": "object",
+	"required": ["serial", "value", "currency"],
+	"properties": {
+		"serial": {"type": "string", "minLength": 1},
+		"value": {"type": "integer", "minimum": 1},
+		"currency": {"type": "string", "enum": ["EUR", "USD"]}
+	},
+	"additionalProperties": false
+}`
+
+func TestJsonSchemaValidate(t *testing.T) {
+	js, err := parseJsonSchema([]byte(noteSchema))
+	assert.Nil(t, err)
+
+	cases := map[string]string{
+		`{"serial":"a1","value":5,"currency":"EUR"}`:              "",
+		`{"serial":"a1","value":5}`:                               `The note needs the property "currency".`,
+		`{"serial":"a1","value":5.5,"currency":"EUR"}`:            `The note.value needs to be "integer".`,
+		`{"serial":"a1","value":0,"currency":"EUR"}`:              "The note.value is less than the minimum.",
+		`{"serial":"","value":5,"currency":"EUR"}`:                "The note.serial is shorter than 1.",
+		`{"serial":"a1","value":5,"currency":"GBP"}`:              "The note.currency is not one of the enum.",
+		`{"serial":"a1","value":5,"currency":"EUR","extra":true}`: `The note can not have the property "extra".`,
+		`[1,2]`: `The note needs to be "object".`,
+	}
+	for input, expected := range cases {
+		var value interface{}
+		json.Unmarshal([]byte(input), &value)
+		err := js.validate(value, "The note")
+		if len(expected) == 0 {
+			assert.Nil(t, err)
+		} else {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+
+	_, err = parseJsonSchema([]byte(`{"type":"money"}`))
+	assert.NotNil(t, err)
+	_, err = parseJsonSchema([]byte(`{"properties":{"serial":{"pattern":"("}}}`))
+	assert.NotNil(t, err)
+
+	// the keywords that the chain does not validate are rejected, not ignored
+	_, err = parseJsonSchema([]byte(`{"type":"object","minProperties":1}`))
+	assert.Equal(t, `The schema has the unknown keyword "minProperties".`, err.Error())
+	_, err = parseJsonSchema([]byte(`{"properties":{"value":{"type":"integer","exclusiveMinimum":0}}}`))
+	assert.Equal(t, `The schema has the unknown keyword "exclusiveMinimum".`, err.Error())
+	_, err = parseJsonSchema([]byte(`{"items":{"format":"email"}}`))
+	assert.Equal(t, `The schema has the unknown keyword "format".`, err.Error())
+	// a null subschema is rejected, it would be a nil schema on the validation
+	_, err = parseJsonSchema([]byte(`{"properties":{"x":null}}`))
+	assert.Equal(t, "The schema needs to be an object, not null.", err.Error())
+	_, err = parseJsonSchema([]byte(`{"items":null}`))
+	assert.Equal(t, "The schema needs to be an object, not null.", err.Error())
+	_, err = parseJsonSchema([]byte(`{"properties":null}`))
+	assert.Equal(t, "The properties of the schema need to be an object.", err.Error())
+	_, err = parseJsonSchema([]byte(`{"properties":{"x":5}}`))
+	assert.NotNil(t, err)
+	_, err = parseJsonSchema([]byte(`{"$schema":"http://json-schema.org/draft-07/schema#","title":"note","type":"object"}`))
+	assert.Nil(t, err)
+}
+
+func TestSpbAddJsonWithRegisteredSchema(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	adminEdKey := utils.createAdmin(t, pba)
+	userEdKey := crypto.GenPrivKeyEd25519()
+
+	hashes := utils.createNotes(t, []string{
+		noteSchema,
+		`{"serial":"s1","value":5,"currency":"EUR"}`,
+		`{"serial":"s2","value":5}`,
+		`{"type":"money"}`,
+	})
+	schema, good, bad, wrongSchema := hashes[0], hashes[1], hashes[2], hashes[3]
+
+	// without registered schemas the json is added as before
+	addDr := utils.createDelivery(t, userEdKey, DeliveryData{Action: ADD_ACTION, Files: []string{bad}})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.CheckTx(b).Code)
+
+	// only the admins register the schemas, and they need to be json schemas
+	registerDr := utils.createDelivery(t, userEdKey, DeliveryData{
+		Action: SCHEMA_REGISTER_ACTION,
+		Reason: utils.str("notes"),
+		Files:  []string{schema},
+	})
+	b, _ = json.Marshal(registerDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	registerDr = utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action: SCHEMA_REGISTER_ACTION,
+		Reason: utils.str("notes"),
+		Files:  []string{wrongSchema},
+	})
+	b, _ = json.Marshal(registerDr)
+	assert.Equal(t, CodeTypeEncodingError, pba.DeliverTx(b).Code)
+
+	registerDr = utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action: SCHEMA_REGISTER_ACTION,
+		Reason: utils.str("notes"),
+		Files:  []string{schema},
+	})
+	b, _ = json.Marshal(registerDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	// a json needs a registered schema, and the CheckTx already validates it
+	addDr = utils.createDelivery(t, userEdKey, DeliveryData{Action: ADD_ACTION, Files: []string{good}})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.CheckTx(b).Code)
+
+	addDr = utils.createDelivery(t, userEdKey, DeliveryData{
+		Action: ADD_ACTION,
+		Files:  []string{good},
+		Schema: &wrongSchema,
+	})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.CheckTx(b).Code)
+
+	addDr = utils.createDelivery(t, userEdKey, DeliveryData{Action: ADD_ACTION, Files: []string{bad}, Schema: &schema})
+	b, _ = json.Marshal(addDr)
+	res := pba.CheckTx(b)
+	assert.Equal(t, CodeTypeEncodingError, res.Code)
+	assert.Equal(t, `The json `+bad+` needs the property "currency".`, res.Log)
+
+	// the files that are not json do not need a schema
+	addDr = utils.createAddOrRemoveDelivery(t, userEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	addDr = utils.createDelivery(t, userEdKey, DeliveryData{Action: ADD_ACTION, Files: []string{good}, Schema: &schema})
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.CheckTx(b).Code)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the query attests the schema of the file
+	q := utils.querySpb(t, userEdKey, &good, nil)
+	b, _ = json.Marshal(q)
+	qr := QueryResponse{}
+	json.Unmarshal(pba.Query(types.RequestQuery{Data: b}).Value, &qr)
+	assert.Equal(t, []FileSchema{{File: good, Schema: schema}}, qr.Schemas)
+
+	b, _ = json.Marshal([]string{schema})
+	assert.Equal(t, types.ResponseQuery{Code: CodeTypeOK, Value: b},
+		pba.Query(types.RequestQuery{Path: "/schemas"}))
+
+	unregisterDr := utils.createDelivery(t, adminEdKey, DeliveryData{
+		Action: SCHEMA_UNREGISTER_ACTION,
+		Reason: utils.str("old notes"),
+		Files:  []string{schema},
+	})
+	b, _ = json.Marshal(unregisterDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	b, _ = json.Marshal([]string{})
+	assert.Equal(t, types.ResponseQuery{Code: CodeTypeOK, Value: b},
+		pba.Query(types.RequestQuery{Path: "/schemas"}))
+	assert.Equal(t, 2, len(pba.auditLog()))
+}
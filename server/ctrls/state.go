@@ -2,20 +2,53 @@ package ctrls
 
 import (
 	"encoding/json"
+	"fmt"
 
 	dbm "github.com/tendermint/tmlibs/db"
 )
 
 var (
-	stateKey = []byte("stateKey")
-	fileKey  = []byte("fileKey:")
-	userKey  = []byte("userKey:")
+	stateKey    = []byte("stateKey")
+	fileKey     = []byte("fileKey:")
+	userKey     = []byte("userKey:")
+	lockKey     = []byte("lockKey:")
+	escrowKey   = []byte("escrowKey:")
+	multisigKey = []byte("multisigKey:")
+	retiredKey  = []byte("retiredKey:")
+	guardianKey = []byte("guardianKey:")
+	recoveryKey = []byte("recoveryKey:")
+	inheritKey  = []byte("inheritKey:")
+	activityKey = []byte("activityKey:")
+	leaseKey    = []byte("leaseKey:")
+	vestKey     = []byte("vestKey:")
+	approveKey  = []byte("approveKey:")
+	subKey      = []byte("subKey:")
+	frozenKey   = []byte("frozenKey:")
+	auditKey    = []byte("auditKey:")
+	bannedKey   = []byte("bannedKey:")
+	registerKey = []byte("registerKey:")
+	nameKey     = []byte("nameKey:")
+	burnedKey   = []byte("burnedKey:")
+	issuerKey   = []byte("issuerKey:")
+	schemaKey   = []byte("schemaKey:")
+	attestKey   = []byte("attestKey:")
+	alertKey    = []byte("alertKey:")
+
+	auditCountKey = []byte("auditCountKey")
+	pausedKey     = []byte("pausedKey")
+	issuersKey    = []byte("issuersKey")
+	adminsKey     = []byte("adminsKey")
 )
 
+// escrowHolder is the owner of the files in escrow, it is not an address
+// so no key can send or remove them.
+const escrowHolder = "escrow"
+
 type State struct {
 	db      dbm.DB
 	Size    int64  `json:"size"`
 	Height  int64  `json:"height"`
+	Time    int64  `json:"time"` // the block time of the current block in unix seconds
 	AppHash []byte `json:"app_hash"`
 }
 
@@ -40,6 +73,43 @@ func saveState(state State) {
 	state.db.Set(stateKey, stateBytes)
 }
 
+// committedState is the part of the state that the pinner and the monitor read outside the consensus,
+// it is copied at each commit, so they never read the db while a block changes it.
+type committedState struct {
+	owners   map[string]string // the hashes of the chain with their owners
+	schemas  []string
+	webhooks map[string]string // the webhooks of the owners for the alerts
+}
+
+// saveCommitted copies the committed state for the pinner and the monitor.
+func (pba *PBApplication) saveCommitted() {
+	if pba.pinner == nil && pba.monitor == nil {
+		return
+	}
+	cs := committedState{owners: pba.ownedFiles(), schemas: pba.getSchemas(), webhooks: pba.getWebhooks()}
+	pba.mtx.Lock()
+	pba.committed = cs
+	pba.mtx.Unlock()
+}
+
+// ownedFiles returns the hashes of the chain with their owners.
+func (pba *PBApplication) ownedFiles() map[string]string {
+	owners := map[string]string{}
+	itr := dbm.IteratePrefix(pba.state.db, fileKey)
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		owners[string(itr.Key()[len(fileKey):])] = string(itr.Value())
+	}
+	return owners
+}
+
+// lastCommitted returns the state of the last commit, it is not changed after that.
+func (pba *PBApplication) lastCommitted() committedState {
+	pba.mtx.Lock()
+	defer pba.mtx.Unlock()
+	return pba.committed
+}
+
 func prefixUserKey(key string) []byte {
 	b := []byte(key)
 	return append(userKey, b...)
@@ -49,3 +119,114 @@ func prefixFileKey(key string) []byte {
 	b := []byte(key)
 	return append(fileKey, b...)
 }
+
+func prefixLockKey(key string) []byte {
+	b := []byte(key)
+	return append(lockKey, b...)
+}
+
+func prefixEscrowKey(key string) []byte {
+	b := []byte(key)
+	return append(escrowKey, b...)
+}
+
+func prefixMultisigKey(key string) []byte {
+	b := []byte(key)
+	return append(multisigKey, b...)
+}
+
+func prefixRetiredKey(key string) []byte {
+	b := []byte(key)
+	return append(retiredKey, b...)
+}
+
+func prefixGuardianKey(key string) []byte {
+	b := []byte(key)
+	return append(guardianKey, b...)
+}
+
+func prefixRecoveryKey(key string) []byte {
+	b := []byte(key)
+	return append(recoveryKey, b...)
+}
+
+func prefixInheritKey(key string) []byte {
+	b := []byte(key)
+	return append(inheritKey, b...)
+}
+
+func prefixActivityKey(key string) []byte {
+	b := []byte(key)
+	return append(activityKey, b...)
+}
+
+func prefixLeaseKey(key string) []byte {
+	b := []byte(key)
+	return append(leaseKey, b...)
+}
+
+func prefixVestKey(key string) []byte {
+	b := []byte(key)
+	return append(vestKey, b...)
+}
+
+func prefixSubKey(key string) []byte {
+	b := []byte(key)
+	return append(subKey, b...)
+}
+
+func prefixRegisterKey(key string) []byte {
+	b := []byte(key)
+	return append(registerKey, b...)
+}
+
+func prefixIssuerKey(key string) []byte {
+	b := []byte(key)
+	return append(issuerKey, b...)
+}
+
+func prefixSchemaKey(key string) []byte {
+	b := []byte(key)
+	return append(schemaKey, b...)
+}
+
+func prefixAttestKey(key string) []byte {
+	b := []byte(key)
+	return append(attestKey, b...)
+}
+
+func prefixAlertKey(key string) []byte {
+	b := []byte(key)
+	return append(alertKey, b...)
+}
+
+func prefixBurnedKey(key string) []byte {
+	b := []byte(key)
+	return append(burnedKey, b...)
+}
+
+func prefixNameKey(key string) []byte {
+	b := []byte(key)
+	return append(nameKey, b...)
+}
+
+func prefixBannedKey(key string) []byte {
+	b := []byte(key)
+	return append(bannedKey, b...)
+}
+
+func prefixFrozenKey(key string) []byte {
+	b := []byte(key)
+	return append(frozenKey, b...)
+}
+
+// prefixAuditKey pads the index, so the entries of the audit log iterate in order.
+func prefixAuditKey(index int64) []byte {
+	b := []byte(fmt.Sprintf("%020d", index))
+	return append(auditKey, b...)
+}
+
+func prefixApproveKey(owner, delegate string) []byte {
+	b := []byte(owner + ":" + delegate)
+	return append(approveKey, b...)
+}
@@ -0,0 +1,126 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/tendermint/go-crypto"
+)
+
+func (pba *PBApplication) getSubKey(addr string) *SubKey {
+	b := pba.state.db.Get(prefixSubKey(addr))
+	if len(b) == 0 {
+		return nil
+	}
+	sk := SubKey{}
+	json.Unmarshal(b, &sk)
+	return &sk
+}
+
+func (sk *SubKey) allows(action ActionStruct) bool {
+	if len(sk.Actions) == 0 {
+		return true
+	}
+	for _, v := range sk.Actions {
+		if v == action {
+			return true
+		}
+	}
+	return false
+}
+
+// subKeyValidation checks that the From is a sub-key of the FromAccount
+// and that the delivery is in the scope of the sub-key.
+func (pba *PBApplication) subKeyValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.FromMultisig != nil {
+		return CodeTypeUnauthorized, errors.New("The sub-key can not sign for a multisig.")
+	}
+	from, _ := crypto.PubKeyFromBytes(dr.Data.From)
+	sk := pba.getSubKey(from.Address().String())
+	if sk == nil || sk.Account != *dr.Data.FromAccount {
+		return CodeTypeUnauthorized, errors.New("The public key is not a sub-key of the account.")
+	}
+	if sk.Expiry != 0 && sk.Expiry <= pba.currentHeight() {
+		return CodeTypeUnauthorized, errors.New("The sub-key has expired.")
+	}
+	// the sub-keys can not give themselves more permissions
+	action := dr.Data.Action
+	if action == SUBKEY_ACTION || action == SUBKEY_REVOKE_ACTION || !sk.allows(action) {
+		return CodeTypeUnauthorized, errors.New("The sub-key is not allowed to " + string(action) + ".")
+	}
+	if action == ADD_ACTION && sk.MaxAdds > 0 && sk.Added+len(dr.Data.Files) > sk.MaxAdds {
+		return CodeTypeUnauthorized,
+			errors.New("The sub-key can add only " + strconv.Itoa(sk.MaxAdds-sk.Added) + " more hashes.")
+	}
+	return CodeTypeOK, nil
+}
+
+// recordSubKeyAdds counts the hashes that the sub-key has added.
+func (pba *PBApplication) recordSubKeyAdds(dr DeliveryRequest) {
+	if dr.Data.FromAccount == nil || dr.Data.Action != ADD_ACTION {
+		return
+	}
+	from, _ := crypto.PubKeyFromBytes(dr.Data.From)
+	sk := pba.getSubKey(from.Address().String())
+	sk.Added += len(dr.Data.Files)
+	b, _ := json.Marshal(sk)
+	pba.state.db.Set(prefixSubKey(from.Address().String()), b)
+}
+
+func (pba *PBApplication) subKeyActionValidation(dr DeliveryRequest) (uint32, error) {
+	sk := dr.Data.SubKey
+	if sk == nil {
+		return CodeTypeUnauthorized, errors.New("The sub-key does not exists.")
+	}
+	pubk, err := crypto.PubKeyFromBytes(sk.PubKey)
+	if err != nil {
+		return CodeTypeEncodingError, errors.New("The public key of the sub-key is not correct.")
+	}
+	subAddr := pubk.Address().String()
+	fromAddr, _ := dr.FromPubKeyAddress()
+	if subAddr == fromAddr {
+		return CodeTypeUnauthorized, errors.New("The sub-key needs to be another key.")
+	}
+	if pba.state.db.Has(prefixSubKey(subAddr)) {
+		return CodeTypeUnauthorized, errors.New("The sub-key " + subAddr + " already exists.")
+	}
+	if sk.MaxAdds < 0 {
+		return CodeTypeUnauthorized, errors.New("The max adds can not be negative.")
+	}
+	if sk.Expiry != 0 && sk.Expiry <= pba.currentHeight() {
+		return CodeTypeUnauthorized, errors.New("The expiry needs to be after the current block height.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) subKeyActionState(dr DeliveryRequest) {
+	fromAddr, _ := dr.FromPubKeyAddress()
+	sk := *dr.Data.SubKey
+	sk.Account = fromAddr
+	sk.Added = 0
+	pubk, _ := crypto.PubKeyFromBytes(sk.PubKey)
+	b, _ := json.Marshal(sk)
+	pba.state.db.Set(prefixSubKey(pubk.Address().String()), b)
+}
+
+func (pba *PBApplication) subKeyRevokeActionValidation(dr DeliveryRequest) (uint32, error) {
+	if dr.Data.SubKey == nil {
+		return CodeTypeUnauthorized, errors.New("The sub-key does not exists.")
+	}
+	pubk, err := crypto.PubKeyFromBytes(dr.Data.SubKey.PubKey)
+	if err != nil {
+		return CodeTypeEncodingError, errors.New("The public key of the sub-key is not correct.")
+	}
+	fromAddr, _ := dr.FromPubKeyAddress()
+	sk := pba.getSubKey(pubk.Address().String())
+	if sk == nil || sk.Account != fromAddr {
+		return CodeTypeUnauthorized, errors.New("The sub-key does not belong to you.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (pba *PBApplication) subKeyRevokeActionState(dr DeliveryRequest) {
+	pubk, _ := crypto.PubKeyFromBytes(dr.Data.SubKey.PubKey)
+	pba.state.db.Delete(prefixSubKey(pubk.Address().String()))
+}
@@ -0,0 +1,116 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/go-crypto"
+)
+
+// signAsSubKey signs the delivery with the sub-key for the account.
+
+func TestSpbSubKeySendOnly(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	accountEdKey := crypto.GenPrivKeyEd25519()
+	subEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	accountAddr := accountEdKey.PubKey().Address().String()
+
+	input := [][]byte{[]byte("random1"), []byte("random2")}
+	addDr := utils.createAddOrRemoveDelivery(t, accountEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	skDr := utils.createDelivery(t, accountEdKey, DeliveryData{
+		Action: SUBKEY_ACTION,
+		SubKey: &SubKey{PubKey: subEdKey.PubKey().Bytes(), Actions: []ActionStruct{SEND_ACTION}},
+	})
+	b, _ = json.Marshal(skDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr := utils.createSendDelivery(t, accountEdKey, &toEdKey, SEND_ACTION, addDr.Data.Files[:1])
+	sendDr = utils.createDelivery(t, subEdKey, utils.asSubKey(sendDr.Data, accountAddr))
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, accountEdKey, REMOVE_ACTION, input[1:])
+	remDr = utils.createDelivery(t, subEdKey, utils.asSubKey(remDr.Data, accountAddr))
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	// the sub-key can not register other sub-keys
+	otherEdKey := crypto.GenPrivKeyEd25519()
+	skDr = utils.createDelivery(t, accountEdKey, DeliveryData{
+		Action: SUBKEY_ACTION,
+		SubKey: &SubKey{PubKey: otherEdKey.PubKey().Bytes()},
+	})
+	skDr = utils.createDelivery(t, subEdKey, utils.asSubKey(skDr.Data, accountAddr))
+	b, _ = json.Marshal(skDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	// after the revoke the sub-key can not send
+	skDr = utils.createDelivery(t, accountEdKey, DeliveryData{
+		Action: SUBKEY_REVOKE_ACTION,
+		SubKey: &SubKey{PubKey: subEdKey.PubKey().Bytes()},
+	})
+	b, _ = json.Marshal(skDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr = utils.createSendDelivery(t, accountEdKey, &toEdKey, SEND_ACTION, addDr.Data.Files[1:])
+	sendDr = utils.createDelivery(t, subEdKey, utils.asSubKey(sendDr.Data, accountAddr))
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
+
+func TestSpbSubKeyMaxAddsAndExpiry(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	accountEdKey := crypto.GenPrivKeyEd25519()
+	subEdKey := crypto.GenPrivKeyEd25519()
+	accountAddr := accountEdKey.PubKey().Address().String()
+
+	skDr := utils.createDelivery(t, accountEdKey, DeliveryData{
+		Action: SUBKEY_ACTION,
+		SubKey: &SubKey{PubKey: subEdKey.PubKey().Bytes(), Actions: []ActionStruct{ADD_ACTION}, MaxAdds: 1, Expiry: 3},
+	})
+	b, _ := json.Marshal(skDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	addDr := utils.createAddOrRemoveDelivery(t, accountEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	addDr = utils.createDelivery(t, subEdKey, utils.asSubKey(addDr.Data, accountAddr))
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	assert.Equal(t, accountAddr, string(pba.state.db.Get(prefixFileKey(addDr.Data.Files[0]))))
+
+	addDr = utils.createAddOrRemoveDelivery(t, accountEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	addDr = utils.createDelivery(t, subEdKey, utils.asSubKey(addDr.Data, accountAddr))
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	skDr = utils.createDelivery(t, accountEdKey, DeliveryData{
+		Action: SUBKEY_REVOKE_ACTION,
+		SubKey: &SubKey{PubKey: subEdKey.PubKey().Bytes()},
+	})
+	b, _ = json.Marshal(skDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+	skDr = utils.createDelivery(t, accountEdKey, DeliveryData{
+		Action: SUBKEY_ACTION,
+		SubKey: &SubKey{PubKey: subEdKey.PubKey().Bytes(), Expiry: 3},
+	})
+	b, _ = json.Marshal(skDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	pba.Commit()
+	pba.Commit()
+
+	// the sub-key has expired
+	addDr = utils.createAddOrRemoveDelivery(t, accountEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	addDr = utils.createDelivery(t, subEdKey, utils.asSubKey(addDr.Data, accountAddr))
+	b, _ = json.Marshal(addDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
@@ -0,0 +1,97 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/tendermint/go-crypto"
+)
+
+// swapActionValidation checks that both parties signed the same delivery
+// and that each one owns the files that gives to the other.
+func (pba *PBApplication) swapActionValidation(dr DeliveryRequest) (uint32, error) {
+	if conf.Conf.Blockchain == conf.OtoOPB {
+		return CodeTypeUnauthorized,
+			errors.New("For one to one blockchain, you can not swap because both keys are already in use.")
+	}
+	if dr.Data.To == nil {
+		return CodeTypeUnauthorized, errors.New("The public key of the receiver does not exists.")
+	}
+
+	to, err := crypto.PubKeyFromBytes(*dr.Data.To)
+	if err != nil {
+		return CodeTypeEncodingError, errors.New("The public key of the receiver is not correct.")
+	}
+
+	from, err := crypto.PubKeyFromBytes(dr.Data.From)
+	if err != nil {
+		return CodeTypeEncodingError, errors.New("The public key of the sender is not correct.")
+	}
+
+	if from == to {
+		return CodeTypeUnauthorized, errors.New("The public key of the receiver is the same as the senders.")
+	}
+
+	if len(dr.Data.Files) == 0 || len(dr.Data.ToFiles) == 0 {
+		return CodeTypeUnauthorized, errors.New("Both sides of the swap need to give at least one file.")
+	}
+
+	b, _ := json.Marshal(dr.Data)
+	sig, err := crypto.SignatureFromBytes(dr.CoSignature)
+	if err != nil {
+		return CodeTypeEncodingError, errors.New("The signature of the receiver is not correct.")
+	}
+	if !to.VerifyBytes(b, sig) {
+		return CodeTypeUnauthorized, errors.New("The signature of the receiver does not validate the data.")
+	}
+
+	fromAddr, _ := dr.FromPubKeyAddress()
+	toAddr, _ := dr.ToPubKeyAddress()
+	// both parties receive files, so both need to be registered
+	for _, v := range []string{fromAddr, toAddr} {
+		code, err := pba.registeredValidation(v)
+		if err != nil {
+			return code, err
+		}
+	}
+
+	for _, v := range dr.Data.Files {
+		user := pba.state.db.Get(prefixFileKey(v))
+		if string(user) != fromAddr {
+			return CodeTypeUnauthorized, errors.New("You dont own The hash " + v + ".")
+		}
+	}
+
+	for _, v := range dr.Data.ToFiles {
+		user := pba.state.db.Get(prefixFileKey(v))
+		if string(user) != toAddr {
+			return CodeTypeUnauthorized, errors.New("The receiver does not own The hash " + v + ".")
+		}
+	}
+	files := append(append([]string{}, dr.Data.Files...), dr.Data.ToFiles...)
+	code, err := pba.bannedFilesValidation(files)
+	if err != nil {
+		return code, err
+	}
+	return pba.movableFilesValidation(files)
+}
+
+// swapActionState exchanges the files between the two parties,
+// it runs only after both sides have been validated so the swap happens as a whole.
+func (pba *PBApplication) swapActionState(dr DeliveryRequest) {
+	toAddr, _ := dr.ToPubKeyAddress()
+	fromAddr, _ := dr.FromPubKeyAddress()
+
+	pba.removeFilesFromUserKey(fromAddr, dr.Data.Files)
+	pba.removeFilesFromUserKey(toAddr, dr.Data.ToFiles)
+	pba.addFilesToUserKey(toAddr, dr.Data.Files)
+	pba.addFilesToUserKey(fromAddr, dr.Data.ToFiles)
+
+	for _, v := range dr.Data.Files {
+		pba.state.db.Set(prefixFileKey(v), []byte(toAddr))
+	}
+	for _, v := range dr.Data.ToFiles {
+		pba.state.db.Set(prefixFileKey(v), []byte(fromAddr))
+	}
+}
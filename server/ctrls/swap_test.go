@@ -0,0 +1,100 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbSwapSuccessfully(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	addDrFrom := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDrFrom)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	addDrTo := utils.createAddOrRemoveDelivery(t, toEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	b, _ = json.Marshal(addDrTo)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	swapDr := utils.coSign(utils.createDelivery(t, fromEdKey, DeliveryData{
+		Action:  SWAP_ACTION,
+		To:      utils.pubKey(toEdKey),
+		Files:   addDrFrom.Data.Files,
+		ToFiles: addDrTo.Data.Files,
+	}), toEdKey)
+	b, _ = json.Marshal(swapDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr := utils.createAddOrRemoveDelivery(t, fromEdKey, REMOVE_ACTION, [][]byte{[]byte("random2")})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	remDr = utils.createAddOrRemoveDelivery(t, toEdKey, REMOVE_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbSwapFailWithoutCoSignature(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	addDrFrom := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDrFrom)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	addDrTo := utils.createAddOrRemoveDelivery(t, toEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	b, _ = json.Marshal(addDrTo)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	swapDr := utils.coSign(utils.createDelivery(t, fromEdKey, DeliveryData{
+		Action:  SWAP_ACTION,
+		To:      utils.pubKey(toEdKey),
+		Files:   addDrFrom.Data.Files,
+		ToFiles: addDrTo.Data.Files,
+	}), toEdKey)
+	swapDr.CoSignature = nil
+	b, _ = json.Marshal(swapDr)
+	assert.Equal(t, CodeTypeEncodingError, pba.DeliverTx(b).Code)
+
+	// the files did not move, so the sender can still remove its own file
+	remDr := utils.createAddOrRemoveDelivery(t, fromEdKey, REMOVE_ACTION, [][]byte{[]byte("random1")})
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbSwapFailWhenReceiverDoesNotOwnTheFiles(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	thirdEdKey := crypto.GenPrivKeyEd25519()
+	utils := forTestUtils{}
+
+	addDrFrom := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDrFrom)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	addDrThird := utils.createAddOrRemoveDelivery(t, thirdEdKey, ADD_ACTION, [][]byte{[]byte("random2")})
+	b, _ = json.Marshal(addDrThird)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	swapDr := utils.coSign(utils.createDelivery(t, fromEdKey, DeliveryData{
+		Action:  SWAP_ACTION,
+		To:      utils.pubKey(toEdKey),
+		Files:   addDrFrom.Data.Files,
+		ToFiles: addDrThird.Data.Files,
+	}), toEdKey)
+	b, _ = json.Marshal(swapDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
@@ -0,0 +1,55 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+func (pba *PBApplication) getVesting(file string) *VestingLock {
+	b := pba.state.db.Get(prefixVestKey(file))
+	if len(b) == 0 {
+		return nil
+	}
+	vl := VestingLock{}
+	json.Unmarshal(b, &vl)
+	return &vl
+}
+
+func (pba *PBApplication) vestingValidation(dr DeliveryRequest) (uint32, error) {
+	vl := dr.Data.Vesting
+	if vl.Height == 0 && vl.Time == 0 {
+		return CodeTypeUnauthorized, errors.New("The vesting needs a block height or a block time.")
+	}
+	if vl.Height != 0 && vl.Height <= pba.currentHeight() {
+		return CodeTypeUnauthorized, errors.New("The vesting height needs to be after the current block height.")
+	}
+	if vl.Time != 0 && vl.Time <= pba.state.Time {
+		return CodeTypeUnauthorized, errors.New("The vesting time needs to be after the current block time.")
+	}
+	return CodeTypeOK, nil
+}
+
+// vestingState replaces the vesting of the sent files,
+// the lock stays with the files when the receiver rotates or recovers its key.
+func (pba *PBApplication) vestingState(dr DeliveryRequest) {
+	for _, v := range dr.Data.Files {
+		if dr.Data.Vesting == nil {
+			pba.state.db.Delete(prefixVestKey(v))
+		} else {
+			b, _ := json.Marshal(dr.Data.Vesting)
+			pba.state.db.Set(prefixVestKey(v), b)
+		}
+	}
+}
+
+// vestingFiles returns the files from the list that are still locked by a vesting.
+func (pba *PBApplication) vestingFiles(files []string) []FileVesting {
+	fvs := []FileVesting{}
+	for _, v := range files {
+		vl := pba.getVesting(v)
+		if vl != nil && vl.Locked(pba.currentHeight(), pba.state.Time) {
+			fvs = append(fvs, FileVesting{File: v, Until: *vl})
+		}
+	}
+	return fvs
+}
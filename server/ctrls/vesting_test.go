@@ -0,0 +1,111 @@
+package ctrls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestSpbVestingUntilHeight(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+
+	input := [][]byte{[]byte("random1")}
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, input)
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	vestDr := utils.createDelivery(t, fromEdKey, DeliveryData{
+		Action:  SEND_ACTION,
+		To:      utils.pubKey(toEdKey),
+		Files:   addDr.Data.Files,
+		Vesting: &VestingLock{Height: 3},
+	})
+	b, _ = json.Marshal(vestDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	// the query shows the lock of the file
+	q := utils.querySpb(t, toEdKey, nil, nil)
+	b, _ = json.Marshal(q)
+	qr := QueryResponse{}
+	json.Unmarshal(pba.Query(types.RequestQuery{Data: b}).Value, &qr)
+	assert.Equal(t, addDr.Data.Files, qr.Files)
+	assert.Equal(t, []FileVesting{{File: addDr.Data.Files[0], Until: VestingLock{Height: 3}}}, qr.Vesting)
+
+	remDr := utils.createAddOrRemoveDelivery(t, toEdKey, REMOVE_ACTION, input)
+	b, _ = json.Marshal(remDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	pba.Commit()
+	pba.Commit()
+
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbVestingUntilTime(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+	thirdEdKey := crypto.GenPrivKeyEd25519()
+
+	pba.BeginBlock(types.RequestBeginBlock{Header: types.Header{Time: 1000}})
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	vestDr := utils.createDelivery(t, fromEdKey, DeliveryData{
+		Action:  SEND_ACTION,
+		To:      utils.pubKey(toEdKey),
+		Files:   addDr.Data.Files,
+		Vesting: &VestingLock{Time: 2000},
+	})
+	b, _ = json.Marshal(vestDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	sendDr := utils.createSendDelivery(t, toEdKey, &thirdEdKey, SEND_ACTION, addDr.Data.Files)
+	b, _ = json.Marshal(sendDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	pba.BeginBlock(types.RequestBeginBlock{Header: types.Header{Time: 2000}})
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+}
+
+func TestSpbVestingFailInThePast(t *testing.T) {
+	conf.Conf.Blockchain = conf.SPB
+	pba := NewPBApplication()
+	utils := forTestUtils{}
+	fromEdKey := crypto.GenPrivKeyEd25519()
+	toEdKey := crypto.GenPrivKeyEd25519()
+
+	addDr := utils.createAddOrRemoveDelivery(t, fromEdKey, ADD_ACTION, [][]byte{[]byte("random1")})
+	b, _ := json.Marshal(addDr)
+	assert.Equal(t, CodeTypeOK, pba.DeliverTx(b).Code)
+
+	vestDr := utils.createDelivery(t, fromEdKey, DeliveryData{
+		Action:  SEND_ACTION,
+		To:      utils.pubKey(toEdKey),
+		Files:   addDr.Data.Files,
+		Vesting: &VestingLock{Height: 1},
+	})
+	b, _ = json.Marshal(vestDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+
+	vestDr = utils.createDelivery(t, fromEdKey, DeliveryData{
+		Action:  SEND_ACTION,
+		To:      utils.pubKey(toEdKey),
+		Files:   addDr.Data.Files,
+		Vesting: &VestingLock{},
+	})
+	b, _ = json.Marshal(vestDr)
+	assert.Equal(t, CodeTypeUnauthorized, pba.DeliverTx(b).Code)
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"time"
 
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/mragiadakos/planetary-blockchain/server/conf"
@@ -14,15 +18,27 @@ import (
 	tmlog "github.com/tendermint/tmlibs/log"
 )
 
-func main() {
-	logger := tmlog.NewTMLogger(kitlog.NewSyncWriter(os.Stdout))
-	flagAbci := "socket"
-	ipfsDaemon := flag.String("ipfs", "127.0.0.1:5001", "the URL for the IPFS's daemon")
-	node := flag.String("node", "tcp://127.0.0.1:26658", "the TCP URL for the ABCI daemon")
-	ipfsAuthorizedUserHash := flag.String("auth", "", "the IPFS hash with the JSON list of public key addresses")
-	waitSec := flag.Int("wait", 5, "the seconds for an acceptable query")
-	blockchainType := flag.String("type", "spb", "the blockchain types are allowed SPB as 'spb' and OtoOPB as 'otoopb'")
-	flag.Parse()
+// configure sets the configuration from the flags and loads the authorized addresses,
+// the flags need to be parsed before, because the -auth and the -ipfs change them.
+func configure(args []string) error {
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	ipfsDaemon := flags.String("ipfs", "127.0.0.1:5001", "the URL for the IPFS's daemon")
+	node := flags.String("node", "tcp://127.0.0.1:26658", "the TCP URL for the ABCI daemon")
+	ipfsAuthorizedUserHash := flags.String("auth", "", "the IPFS hash with the JSON list of public key addresses")
+	waitSec := flags.Int("wait", 5, "the seconds for an acceptable query")
+	permissioned := flags.Bool("permissioned", false, "only the addresses that the admins registered can add, send and receive")
+	notes := flags.Bool("notes", false, "the json hashes are notes with a 'value' that can be split and merged")
+	pin := flags.Bool("pin", false, "pin the hashes of the chain in the IPFS after each commit")
+	pinsFile := flags.String("pins-file", "pins.json", "the file that keeps the hashes that the node pinned, so a restart can unpin them")
+	pinsAdmin := flags.String("pins-admin", "127.0.0.1:26661", "the local address for the reconciliation of the pins, empty disables it")
+	monitor := flags.Int("monitor", 0, "the seconds between the rounds that check the hashes in the IPFS, 0 disables the monitor")
+	monitorTimeout := flags.Int("monitor-timeout", 10, "the seconds that the IPFS has to return a hash for the monitor")
+	metrics := flags.String("metrics", "", "the address for the metrics of the monitor, like ':26660'")
+	blockchainType := flags.String("type", "spb", "the blockchain types are allowed SPB as 'spb' and OtoOPB as 'otoopb'")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
 
 	if len(*ipfsAuthorizedUserHash) > 0 {
 		conf.Conf.AuthorizedAddressesIpfsHash = *ipfsAuthorizedUserHash
@@ -30,12 +46,59 @@ func main() {
 	conf.Conf.AbciDaemon = *node
 	conf.Conf.IpfsConnection = *ipfsDaemon
 	conf.Conf.WaitingSecondsQuery = *waitSec
+	conf.Conf.Permissioned = *permissioned
+	conf.Conf.Notes = *notes
+	conf.Conf.Pin = *pin
+	conf.Conf.PinsFile = *pinsFile
+	// the reconciliation is only for the operator of the node, so it is not served outside the machine
+	if len(*pinsAdmin) > 0 {
+		host, _, err := net.SplitHostPort(*pinsAdmin)
+		ip := net.ParseIP(host)
+		if err != nil || (host != "localhost" && (ip == nil || !ip.IsLoopback())) {
+			return errors.New("The -pins-admin needs a loopback address like '127.0.0.1:26661'")
+		}
+	}
+	conf.Conf.PinsAddress = *pinsAdmin
+	conf.Conf.MonitorSeconds = *monitor
+	conf.Conf.MonitorTimeoutSeconds = *monitorTimeout
+	conf.Conf.MetricsAddress = *metrics
 	if *blockchainType != "spb" && *blockchainType != "otopb" {
-		log.Fatal("There is not such a type, try 'spb' or 'otopb'")
+		return errors.New("There is not such a type, try 'spb' or 'otopb'")
 	}
 	conf.Conf.Blockchain = conf.BlockchainType(*blockchainType)
 
+	// the authorized addresses are loaded from the IPFS of the -ipfs, after the flags
+	return conf.Conf.SetAuthorizedAddresses()
+}
+
+func main() {
+	logger := tmlog.NewTMLogger(kitlog.NewSyncWriter(os.Stdout))
+	flagAbci := "socket"
+	err := configure(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
 	app := ctrls.NewPBApplication()
+	if conf.Conf.Pin {
+		err = app.EnablePinning(conf.Conf.PinsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(conf.Conf.PinsAddress) > 0 {
+			go func() {
+				log.Fatal(http.ListenAndServe(conf.Conf.PinsAddress, http.HandlerFunc(app.ServeReconcile)))
+			}()
+		}
+	}
+	if conf.Conf.MonitorSeconds > 0 {
+		app.EnableMonitor(time.Duration(conf.Conf.MonitorSeconds)*time.Second,
+			time.Duration(conf.Conf.MonitorTimeoutSeconds)*time.Second)
+		if len(conf.Conf.MetricsAddress) > 0 {
+			go func() {
+				log.Fatal(http.ListenAndServe(conf.Conf.MetricsAddress, http.HandlerFunc(app.ServeMetrics)))
+			}()
+		}
+	}
 	srv, err := absrv.NewServer(conf.Conf.AbciDaemon, flagAbci, app)
 	if err != nil {
 		fmt.Println("Error ", err)
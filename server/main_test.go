@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ipfs/go-ipfs-api"
+	"github.com/mragiadakos/planetary-blockchain/server/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/go-crypto"
+)
+
+func TestConfigureLoadsTheAuthorizedAddressesOfTheFlag(t *testing.T) {
+	admin1 := crypto.GenPrivKeyEd25519().PubKey().Address().String()
+	admin2 := crypto.GenPrivKeyEd25519().PubKey().Address().String()
+	sh := shell.NewShell(conf.Conf.IpfsConnection)
+	b, _ := json.Marshal([]string{admin1, admin2})
+	hash, err := sh.BlockPut(b)
+	assert.Nil(t, err)
+
+	err = configure([]string{"-type=spb", "-auth=" + hash, "-permissioned"})
+	assert.Nil(t, err)
+	assert.Equal(t, hash, conf.Conf.AuthorizedAddressesIpfsHash)
+	assert.True(t, conf.Conf.Permissioned)
+	_, ok := conf.Conf.GetAuthorizedAddresses()[admin1]
+	assert.True(t, ok)
+	_, ok = conf.Conf.GetAuthorizedAddresses()[admin2]
+	assert.True(t, ok)
+	conf.Conf.Permissioned = false
+}
+
+func TestConfigureFailsWithoutTheAuthorizedAddresses(t *testing.T) {
+	sh := shell.NewShell(conf.Conf.IpfsConnection)
+	notJson, err := sh.BlockPut([]byte("not a json"))
+	assert.Nil(t, err)
+
+	assert.NotNil(t, configure([]string{"-auth=QmDoesNotExists"}))
+	assert.NotNil(t, configure([]string{"-auth=" + notJson}))
+	assert.NotNil(t, configure([]string{"-type=other"}))
+	assert.NotNil(t, configure([]string{"-pins-admin=0.0.0.0:26661"}))
+	assert.NotNil(t, configure([]string{"-pins-admin=:26661"}))
+
+	b, _ := json.Marshal([]string{crypto.GenPrivKeyEd25519().PubKey().Address().String()})
+	hash, err := sh.BlockPut(b)
+	assert.Nil(t, err)
+	assert.Nil(t, configure([]string{"-auth=" + hash}))
+	assert.Nil(t, configure([]string{"-auth=" + hash, "-pins-admin=[::1]:26661"}))
+}